@@ -35,10 +35,10 @@ import (
 const pluginName = "process-manager-example"
 
 func main() {
-	pmPlugin := pm.DefaultPlugin
+	pmPlugin := &pm.DefaultPlugin
 	example := &PMExample{
 		Log:      logging.ForPlugin(pluginName),
-		PM:       &pmPlugin,
+		PM:       pmPlugin,
 		finished: make(chan struct{}),
 	}
 
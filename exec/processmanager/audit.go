@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// AuditEvent describes exactly what was executed when the process was started
+type AuditEvent struct {
+	// Process name
+	Name string
+	// Time of the start
+	Time time.Time
+	// Resolved absolute path of the executed binary
+	Path string
+	// Arguments (without the command itself)
+	Args []string
+	// Effective user and group ID of the started process
+	UID, GID uint32
+	// Environment variable names. Values are included only if enabled by WithAuditEnvValues, otherwise
+	// only keys are present
+	Env []string
+	// Working directory
+	Dir string
+	// Process ID
+	Pid int
+}
+
+// AuditHook is called with audit event every time the process is started
+type AuditHook func(event *AuditEvent)
+
+// creates audit event for started command
+func (p *Process) newAuditEvent(cmd *exec.Cmd) *AuditEvent {
+	event := &AuditEvent{
+		Name: p.name,
+		Time: p.startTime,
+		Path: cmd.Path,
+		Dir:  cmd.Dir,
+		UID:  uint32(os.Geteuid()),
+		GID:  uint32(os.Getegid()),
+	}
+	if resolved, err := filepath.EvalSymlinks(cmd.Path); err == nil {
+		event.Path = resolved
+	}
+	if abs, err := filepath.Abs(event.Path); err == nil {
+		event.Path = abs
+	}
+	if len(cmd.Args) > 1 {
+		event.Args = cmd.Args[1:]
+	}
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.Credential != nil {
+		event.UID = cmd.SysProcAttr.Credential.Uid
+		event.GID = cmd.SysProcAttr.Credential.Gid
+	}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	for _, variable := range env {
+		if p.options == nil || !p.options.auditEnvValues {
+			variable = strings.SplitN(variable, "=", 2)[0]
+		}
+		event.Env = append(event.Env, variable)
+	}
+	if cmd.Process != nil {
+		event.Pid = cmd.Process.Pid
+	}
+	return event
+}
+
+// audit logs the start of the process and passes the event to the audit hook (if defined)
+func (p *Process) audit(cmd *exec.Cmd) {
+	event := p.newAuditEvent(cmd)
+	p.log.WithFields(logging.Fields{
+		"audit": "process-start",
+		"name":  event.Name,
+		"path":  event.Path,
+		"args":  event.Args,
+		"uid":   event.UID,
+		"gid":   event.GID,
+		"env":   event.Env,
+		"dir":   event.Dir,
+		"pid":   event.Pid,
+	}).Info("process started")
+
+	if p.options != nil && p.options.auditHook != nil {
+		p.runHook(func() {
+			p.options.auditHook(event)
+		})
+	}
+}
+
+// runHook calls user-defined hook, a panic in the hook is logged and does not affect the process
+func (p *Process) runHook(hook func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.LogPanic(p.log.WithField("process", p.name), r)
+		}
+	}()
+	hook()
+}
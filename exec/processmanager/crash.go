@@ -0,0 +1,102 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// Environment variables describing the crashed process, passed to the on-crash command
+const (
+	CrashEnvProcess  = "PM_PROCESS"
+	CrashEnvPid      = "PM_PID"
+	CrashEnvCategory = "PM_EXIT_CATEGORY"
+	CrashEnvCode     = "PM_EXIT_CODE"
+	CrashEnvSignal   = "PM_EXIT_SIGNAL"
+)
+
+// defaultOnCrashTimeout is used if the on-crash command was defined without a positive timeout
+const defaultOnCrashTimeout = 10 * time.Second
+
+// maxCrashOutput limits the captured output of the on-crash command
+const maxCrashOutput = 64 * 1024
+
+// isCrash returns true if the process ended on its own with other than clean exit
+func isCrash(exit *ExitClassification) bool {
+	if exit == nil {
+		return false
+	}
+	switch exit.Category {
+	case ExitFailed, ExitSignaled, ExitCrashed, ExitKilled:
+		return true
+	}
+	return false
+}
+
+// runOnCrash runs the on-crash command (if defined) for the crashed process instance and returns its
+// combined output. The command is waited for up to the timeout, errors are only logged.
+func (p *Process) runOnCrash(exit *ExitClassification) string {
+	if p.options == nil || len(p.options.onCrashCmd) == 0 || !isCrash(exit) {
+		return ""
+	}
+	timeout := p.options.onCrashTimeout
+	if timeout <= 0 {
+		timeout = defaultOnCrashTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	onCrash := p.options.onCrashCmd
+	cmd := exec.CommandContext(ctx, onCrash[0], onCrash[1:]...)
+	cmd.Env = append(os.Environ(),
+		CrashEnvProcess+"="+p.name,
+		CrashEnvPid+"="+strconv.Itoa(exit.Pid),
+		CrashEnvCategory+"="+string(exit.Category),
+		CrashEnvCode+"="+strconv.Itoa(exit.ExitCode),
+		CrashEnvSignal+"="+exit.Signal,
+	)
+	var out bytes.Buffer
+	cmd.Stdout = &limitedBuffer{buf: &out, limit: maxCrashOutput}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = ctx.Err()
+		}
+		p.log.Warnf("On-crash command for process %s failed: %v", p.name, err)
+	} else {
+		p.log.Debugf("On-crash command for process %s finished", p.name)
+	}
+	return out.String()
+}
+
+// limitedBuffer discards writes exceeding the limit
+type limitedBuffer struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(data []byte) (int, error) {
+	if free := b.limit - b.buf.Len(); free < len(data) {
+		if free > 0 {
+			b.buf.Write(data[:free])
+		}
+		return len(data), nil
+	}
+	return b.buf.Write(data)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCronSchedule(t *testing.T) {
+	RegisterTestingT(t)
+
+	// Wednesday
+	from := time.Date(2020, 1, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"*/15 * * * *", time.Date(2020, 1, 1, 12, 45, 0, 0, time.UTC)},
+		{"5/15 * * * *", time.Date(2020, 1, 1, 12, 35, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2020, 1, 2, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"30 1 15 2 *", time.Date(2020, 2, 15, 1, 30, 0, 0, time.UTC)},
+		{"0 12-14 * * 1-5", time.Date(2020, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"0 0 10 * 5", time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		schedule, err := parseCron(test.expr)
+		Expect(err).To(BeNil(), test.expr)
+		Expect(schedule.next(from)).To(Equal(test.next), test.expr)
+	}
+
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := parseCron(invalid)
+		Expect(err).NotTo(BeNil(), invalid)
+	}
+}
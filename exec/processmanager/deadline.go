@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"time"
+
+	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
+)
+
+// newDeadlineTimer returns timer of the lifecycle deadline, or nil if the deadline is not set
+func newDeadlineTimer(options *POptions) *time.Timer {
+	if options == nil || options.lifecycleDeadline <= 0 {
+		return nil
+	}
+	return time.NewTimer(options.lifecycleDeadline)
+}
+
+// exceedDeadline disables automatic restarts of the process and stops it in the background. Returns false
+// if the process is not running, so there is nothing to stop.
+func (p *Process) exceedDeadline() bool {
+	p.mx.Lock()
+	p.deadlineExceeded = true
+	p.mx.Unlock()
+
+	if !p.isAlive() {
+		p.log.Infof("Lifecycle deadline of process %s exceeded while it was not running", p.name)
+		return false
+	}
+	p.log.Warnf("Lifecycle deadline of process %s exceeded, stopping it", p.name)
+	go func() {
+		if err := p.stopWithTimeout(nil); err != nil {
+			p.log.Errorf("failed to stop process %s after its lifecycle deadline: %v", p.name, err)
+		}
+	}()
+	return true
+}
+
+func (p *Process) isDeadlineExceeded() bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.deadlineExceeded
+}
+
+// reportDeadline sends the terminal deadline-exceeded notification and event
+func (p *Process) reportDeadline(from status.ProcessStatus) {
+	p.notify(status.DeadlineExceeded)
+	p.publish(from, status.DeadlineExceeded, "")
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "pm-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	content := `# comment
+PLAIN=value
+export EXPORTED=yes
+
+SPACED = trimmed value # trailing comment
+DOUBLE="line\nbreak \"quoted\" # kept"
+SINGLE='literal \n # kept'
+EMPTY=
+`
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	env, err := loadEnvFile(file.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"PLAIN=value",
+		"EXPORTED=yes",
+		"SPACED=trimmed value",
+		"DOUBLE=line\nbreak \"quoted\" # kept",
+		`SINGLE=literal \n # kept`,
+		"EMPTY=",
+	}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("expected %q, got %q", expected, env)
+	}
+
+	for _, invalid := range []string{"NOVALUE", "=value", `KEY="unterminated`, "KEY='unterminated"} {
+		if err := ioutil.WriteFile(file.Name(), []byte(invalid), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadEnvFile(file.Name()); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+	if _, err := loadEnvFile(file.Name() + "-missing"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	merged := mergeEnv([]string{"A=1", "B=2", "C=3"}, []string{"B=overridden", "D=4"})
+	expected := []string{"A=1", "B=overridden", "C=3", "D=4"}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+}
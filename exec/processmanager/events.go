@@ -0,0 +1,198 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"sync"
+	"time"
+
+	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
+)
+
+// ProcessEvent describes a transition of the process into a new state
+type ProcessEvent struct {
+	// Process name
+	Process string
+	// PID of the process instance, zero if the process is not running
+	Pid int
+	// Previous and new state
+	From status.ProcessStatus
+	To   status.ProcessStatus
+	// Time when the transition was detected
+	Time time.Time
+	// Exit classification, set on transition to the terminated state if known
+	Exit *ExitClassification
+	// Output of the on-crash command (see WithOnCrash), set on transition to the terminated state
+	Diagnostics string
+}
+
+// On returns a channel receiving an event whenever the process transitions into the given state. Every call
+// creates a new subscription with its own channel, use Off to unsubscribe. Events are buffered, if a subscriber
+// does not keep up, events are dropped (see DroppedEvents). Channels of all subscriptions are closed
+// when the process is deleted.
+func (p *Process) On(state status.ProcessStatus) <-chan ProcessEvent {
+	ch := make(chan ProcessEvent, defaultNotifyBuffer)
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.subscriptions == nil {
+		p.subscriptions = make(map[status.ProcessStatus][]chan ProcessEvent)
+	}
+	p.subscriptions[state] = append(p.subscriptions[state], ch)
+	return ch
+}
+
+// Off cancels the subscription created by On and closes its channel
+func (p *Process) Off(ch <-chan ProcessEvent) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	for state, subs := range p.subscriptions {
+		for i, sub := range subs {
+			if sub == ch {
+				p.subscriptions[state] = append(subs[:i:i], subs[i+1:]...)
+				close(sub)
+				return
+			}
+		}
+	}
+}
+
+// DroppedEvents returns number of events dropped because a subscriber created by On did not keep up
+func (p *Process) DroppedEvents() uint64 {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.droppedEvents
+}
+
+// publish delivers the transition event to subscribers of its target state without blocking
+func (p *Process) publish(from, to status.ProcessStatus, diagnostics string) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	subs := p.subscriptions[to]
+	if len(subs) == 0 && p.events == nil {
+		return
+	}
+	event := ProcessEvent{
+		Process: p.name,
+		Pid:     p.pid,
+		From:    from,
+		To:      to,
+		Time:    time.Now(),
+
+		Diagnostics: diagnostics,
+	}
+	if to == status.Terminated && p.lastExit != nil && p.command != nil && p.command.Process != nil &&
+		p.lastExit.Pid == p.command.Process.Pid {
+		exit := *p.lastExit
+		event.Exit = &exit
+	}
+	for _, sub := range subs {
+		select {
+		case sub <- event:
+		default:
+			p.droppedEvents++
+			p.log.Warnf("Event %q of process %s dropped, subscriber does not keep up", to, p.name)
+		}
+	}
+	if p.events != nil {
+		p.events.publish(event)
+	}
+}
+
+// setEvents sets (or clears, if nil) the aggregated event stream the process contributes to
+func (p *Process) setEvents(events *eventStream) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.events = events
+}
+
+// defaultEventsBuffer is the size of the buffer of the aggregated event stream
+const defaultEventsBuffer = 256
+
+// eventStream aggregates events of all processes of the plugin
+type eventStream struct {
+	mx      sync.Mutex
+	ch      chan ProcessEvent
+	dropped uint64
+}
+
+func (s *eventStream) publish(event ProcessEvent) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.ch == nil {
+		// nobody asked for events yet
+		return
+	}
+	select {
+	case s.ch <- event:
+	default:
+		s.dropped++
+	}
+}
+
+// Events returns a channel receiving events about all transitions of all processes managed by the plugin.
+// Processes added later join the stream automatically, deleted processes stop contributing to it. All calls
+// return the same channel. Events are buffered, if the consumer does not keep up, events are dropped
+// (see DroppedEvents). The channel is never closed.
+func (p *Plugin) Events() <-chan ProcessEvent {
+	events := p.eventStream()
+	events.mx.Lock()
+	defer events.mx.Unlock()
+	if events.ch == nil {
+		events.ch = make(chan ProcessEvent, defaultEventsBuffer)
+	}
+	return events.ch
+}
+
+// DroppedEvents returns number of events dropped because the consumer of Events did not keep up
+func (p *Plugin) DroppedEvents() uint64 {
+	events := p.eventStream()
+	events.mx.Lock()
+	defer events.mx.Unlock()
+	return events.dropped
+}
+
+// eventStream returns the aggregated event stream, created on first use
+func (p *Plugin) eventStream() *eventStream {
+	p.eventsMu.Lock()
+	defer p.eventsMu.Unlock()
+	if p.events == nil {
+		p.events = &eventStream{}
+	}
+	return p.events
+}
+
+// closeSubscriptions closes channels of all subscriptions
+func (p *Process) closeSubscriptions() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	for _, subs := range p.subscriptions {
+		for _, sub := range subs {
+			close(sub)
+		}
+	}
+	p.subscriptions = nil
+}
+
+// moveSubscriptions hands over all subscriptions to the process which replaces this one
+func (p *Process) moveSubscriptions(to *Process) {
+	p.mx.Lock()
+	subscriptions := p.subscriptions
+	p.subscriptions = nil
+	p.mx.Unlock()
+
+	to.mx.Lock()
+	defer to.mx.Unlock()
+	to.subscriptions = subscriptions
+}
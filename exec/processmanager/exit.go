@@ -0,0 +1,187 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// StopReason describes why the plugin stopped the process (if it did)
+type StopReason int
+
+const (
+	// StopReasonNone means the process was not stopped by the plugin
+	StopReasonNone StopReason = iota
+	// StopReasonStop means the process was stopped with Stop or StopAndWait
+	StopReasonStop
+	// StopReasonRestart means the process was stopped to be restarted
+	StopReasonRestart
+	// StopReasonKill means the process was force-stopped with Kill
+	StopReasonKill
+)
+
+// String returns name of the stop reason
+func (r StopReason) String() string {
+	switch r {
+	case StopReasonNone:
+		return "none"
+	case StopReasonStop:
+		return "stop"
+	case StopReasonRestart:
+		return "restart"
+	case StopReasonKill:
+		return "kill"
+	}
+	return fmt.Sprintf("StopReason(%d)", int(r))
+}
+
+// ExitCategory is a category of the process exit
+type ExitCategory string
+
+// Exit categories
+const (
+	ExitClean        ExitCategory = "clean-exit"    // exited with zero code on its own
+	ExitFailed       ExitCategory = "failed"        // exited with non-zero code
+	ExitSignaled     ExitCategory = "signaled"      // terminated by a signal not sent by the plugin
+	ExitCrashed      ExitCategory = "crashed"       // terminated by a fault signal (SIGSEGV, SIGABRT, ...)
+	ExitKilled       ExitCategory = "killed"        // killed by SIGKILL not sent by the plugin (e.g. OOM killer)
+	ExitStopped      ExitCategory = "stopped"       // stopped by the plugin
+	ExitRestarted    ExitCategory = "restarted"     // stopped by the plugin to be restarted
+	ExitForceStopped ExitCategory = "force-stopped" // killed by the plugin
+	ExitUnknown      ExitCategory = "unknown"       // exit status is not available
+)
+
+// ExitClassification describes why the process ended
+type ExitClassification struct {
+	Pid         int          `json:"pid"`
+	Category    ExitCategory `json:"category"`
+	Description string       `json:"description"`
+	ExitCode    int          `json:"exit_code"`
+	Signal      string       `json:"signal,omitempty"`
+	StopReason  string       `json:"stop_reason"`
+}
+
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGTRAP: "SIGTRAP",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGUSR1: "SIGUSR1",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGUSR2: "SIGUSR2",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGALRM: "SIGALRM",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+var faultSignals = map[syscall.Signal]bool{
+	syscall.SIGILL:  true,
+	syscall.SIGTRAP: true,
+	syscall.SIGABRT: true,
+	syscall.SIGBUS:  true,
+	syscall.SIGFPE:  true,
+	syscall.SIGSEGV: true,
+}
+
+func signalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("signal %d", int(sig))
+}
+
+// ClassifyExit combines the exit status of the process with the reason the plugin stopped it (if any)
+// into a single categorized result. A process killed by SIGKILL not sent by the plugin is classified
+// as killed, the source of the signal (OOM killer, another process) cannot be told from the exit status.
+func ClassifyExit(state *os.ProcessState, reason StopReason) ExitClassification {
+	c := ExitClassification{
+		Category:   ExitUnknown,
+		ExitCode:   -1,
+		StopReason: reason.String(),
+	}
+	var ws syscall.WaitStatus
+	var ok bool
+	if state != nil {
+		c.Pid = state.Pid()
+		ws, ok = state.Sys().(syscall.WaitStatus)
+	}
+	if ok && ws.Signaled() {
+		c.Signal = signalName(ws.Signal())
+	} else if ok {
+		c.ExitCode = ws.ExitStatus()
+	}
+
+	switch {
+	case reason == StopReasonKill:
+		c.Category, c.Description = ExitForceStopped, "force-stopped by supervisor"
+	case reason == StopReasonRestart:
+		c.Category, c.Description = ExitRestarted, "stopped by supervisor for restart"
+	case reason == StopReasonStop:
+		c.Category, c.Description = ExitStopped, "stopped by supervisor"
+	case !ok:
+		c.Description = "exit status not available"
+	case ws.Signaled() && ws.Signal() == syscall.SIGKILL:
+		c.Category, c.Description = ExitKilled, "killed (SIGKILL)"
+	case ws.Signaled() && faultSignals[ws.Signal()]:
+		c.Category, c.Description = ExitCrashed, fmt.Sprintf("crashed (%s)", c.Signal)
+	case ws.Signaled():
+		c.Category, c.Description = ExitSignaled, fmt.Sprintf("killed by %s", c.Signal)
+	case c.ExitCode == 0:
+		c.Category, c.Description = ExitClean, "clean exit"
+	default:
+		c.Category, c.Description = ExitFailed, fmt.Sprintf("exited with code %d", c.ExitCode)
+	}
+	return c
+}
+
+func (p *Process) setStopReason(reason StopReason) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	// stop done as part of a restart keeps the restart reason, kill overrides any reason
+	if p.stopReason == StopReasonNone || reason == StopReasonKill || reason == StopReasonNone {
+		p.stopReason = reason
+	}
+}
+
+// stoppedByOperator returns true if the current process instance was stopped or killed by the plugin,
+// other than as part of a restart
+func (p *Process) stoppedByOperator() bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.stopReason == StopReasonStop || p.stopReason == StopReasonKill
+}
+
+// records classification of the exit of the reaped process instance
+func (p *Process) setLastExit(state *os.ProcessState) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	exit := ClassifyExit(state, p.stopReason)
+	p.lastExit = &exit
+}
+
+// LastExit returns classification of the last exit of the process, or nil if no process instance
+// was reaped yet
+func (p *Process) LastExit() *ExitClassification {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.lastExit
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestClassifyExit(t *testing.T) {
+	tests := []struct {
+		script   string
+		reason   StopReason
+		expected ExitCategory
+		desc     string
+	}{
+		{"exit 0", StopReasonNone, ExitClean, "clean exit"},
+		{"exit 3", StopReasonNone, ExitFailed, "exited with code 3"},
+		{"kill -TERM $$", StopReasonNone, ExitSignaled, "killed by SIGTERM"},
+		{"kill -SEGV $$", StopReasonNone, ExitCrashed, "crashed (SIGSEGV)"},
+		{"kill -KILL $$", StopReasonNone, ExitKilled, "killed (SIGKILL)"},
+		{"kill -TERM $$", StopReasonStop, ExitStopped, "stopped by supervisor"},
+		{"kill -KILL $$", StopReasonKill, ExitForceStopped, "force-stopped by supervisor"},
+	}
+	for _, test := range tests {
+		cmd := exec.Command("/bin/sh", "-c", test.script)
+		_ = cmd.Run()
+		exit := ClassifyExit(cmd.ProcessState, test.reason)
+		if exit.Category != test.expected || exit.Description != test.desc {
+			t.Errorf("%q (reason %v): unexpected classification %+v", test.script, test.reason, exit)
+		}
+	}
+
+	if exit := ClassifyExit(nil, StopReasonNone); exit.Category != ExitUnknown {
+		t.Errorf("unexpected classification of missing state: %+v", exit)
+	}
+}
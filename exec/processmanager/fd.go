@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"io/ioutil"
+	"runtime"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
+)
+
+// fdWarnRatio is the part of the FD limit at which the warning is emitted
+const fdWarnRatio = 0.9
+
+// ErrFDCountUnsupported is returned by CountOpenFiles on systems without /proc/<pid>/fd (non-Linux)
+var ErrFDCountUnsupported = errors.New("counting open file descriptors is supported only on Linux")
+
+// CountOpenFiles returns number of file descriptors opened by the process with given PID
+func CountOpenFiles(pid int) (int, error) {
+	if runtime.GOOS != "linux" {
+		return 0, ErrFDCountUnsupported
+	}
+	fds, err := ioutil.ReadDir("/proc/" + strconv.Itoa(pid) + "/fd")
+	if err != nil {
+		return 0, errors.Errorf("failed to read open file descriptors of process %d: %v", pid, err)
+	}
+	return len(fds), nil
+}
+
+// fdMonitor checks number of open file descriptors of the process against the limit (see WithFDLimit)
+type fdMonitor struct {
+	limit    int
+	restart  bool
+	warned   bool
+	disabled bool
+}
+
+func newFDMonitor(options *POptions) *fdMonitor {
+	if options == nil || options.fdLimit <= 0 {
+		return nil
+	}
+	return &fdMonitor{limit: options.fdLimit, restart: options.fdLimitRestart}
+}
+
+// check samples open file descriptors of the running process. A warning is emitted once when the count
+// crosses the warning threshold, and again only after it dropped below it. Returns true if the process
+// should be restarted.
+func (m *fdMonitor) check(p *Process, pid int) (restart bool) {
+	if m == nil || m.disabled {
+		return false
+	}
+	count, err := CountOpenFiles(pid)
+	if err == ErrFDCountUnsupported {
+		p.log.Warnf("FD limit of process %s is not monitored: %v", p.name, err)
+		m.disabled = true
+		return false
+	} else if err != nil {
+		p.log.Debug(err)
+		return false
+	}
+	if count < int(float64(m.limit)*fdWarnRatio) {
+		m.warned = false
+		return false
+	}
+	if !m.warned {
+		p.log.Warnf("process %s has %d open file descriptors (limit %d)", p.name, count, m.limit)
+		p.notify(status.FDLimitWarning)
+		m.warned = true
+	}
+	if count >= m.limit && m.restart {
+		p.log.Warnf("process %s exceeded limit of %d open file descriptors, restarting", p.name, m.limit)
+		m.warned = false
+		return true
+	}
+	return false
+}
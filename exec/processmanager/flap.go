@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"time"
+)
+
+const (
+	// restartHistorySize is the number of the last restart times kept for each process
+	restartHistorySize = 10
+	// flapWindow is the period in which restarts are counted into the flap score
+	flapWindow = 10 * time.Minute
+)
+
+// FlapScore summarizes how frequently the process was restarted recently. It is the number of restarts
+// (automatic or requested) within the last 10 minutes divided by 10, capped at 1. Zero means the process
+// did not restart recently, 1 means it restarted at least 10 times and is likely flapping.
+func (p *Process) FlapScore() float64 {
+	return flapScore(p.RestartHistory(), time.Now())
+}
+
+// RestartHistory returns times of the last (up to 10) restarts of the process, the oldest first
+func (p *Process) RestartHistory() []time.Time {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	history := make([]time.Time, len(p.restartTimes))
+	copy(history, p.restartTimes)
+	return history
+}
+
+// recordRestart adds the restart time to the history, dropping the oldest time if the history is full
+func (p *Process) recordRestart(t time.Time) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if len(p.restartTimes) == restartHistorySize {
+		p.restartTimes = append(p.restartTimes[:0], p.restartTimes[1:]...)
+	}
+	p.restartTimes = append(p.restartTimes, t)
+}
+
+func flapScore(history []time.Time, now time.Time) float64 {
+	var recent int
+	for _, t := range history {
+		if now.Sub(t) <= flapWindow {
+			recent++
+		}
+	}
+	if recent >= restartHistorySize {
+		return 1
+	}
+	return float64(recent) / restartHistorySize
+}
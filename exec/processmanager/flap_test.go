@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlapScore(t *testing.T) {
+	now := time.Now()
+	var history []time.Time
+	for i := 0; i < 5; i++ {
+		history = append(history, now.Add(-time.Duration(i)*time.Minute))
+	}
+	old := []time.Time{now.Add(-time.Hour), now.Add(-2 * flapWindow)}
+
+	tests := []struct {
+		name     string
+		history  []time.Time
+		expected float64
+	}{
+		{"no restarts", nil, 0},
+		{"recent restarts", history, 0.5},
+		{"old restarts", old, 0},
+		{"mixed restarts", append(old, history[:2]...), 0.2},
+		{"many restarts", append(append(history, history...), history...), 1},
+	}
+	for _, test := range tests {
+		if score := flapScore(test.history, now); score != test.expected {
+			t.Errorf("%s: expected score %v, got %v", test.name, test.expected, score)
+		}
+	}
+}
+
+func TestRestartHistory(t *testing.T) {
+	p := &Process{}
+	start := time.Now()
+	for i := 0; i < restartHistorySize+3; i++ {
+		p.recordRestart(start.Add(time.Duration(i) * time.Second))
+	}
+	history := p.RestartHistory()
+	if len(history) != restartHistorySize {
+		t.Fatalf("expected %d restarts in history, got %d", restartHistorySize, len(history))
+	}
+	if !history[0].Equal(start.Add(3 * time.Second)) {
+		t.Errorf("expected oldest restarts to be dropped, first is %v", history[0].Sub(start))
+	}
+	if score := p.FlapScore(); score != 1 {
+		t.Errorf("expected score 1, got %v", score)
+	}
+}
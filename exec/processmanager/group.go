@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ProcessGroup is an ordered set of processes (for example replicas of the same service) which are
+// managed together. Order of members is preserved for all group operations.
+type ProcessGroup struct {
+	name    string
+	members []ProcessInstance
+}
+
+// NewProcessGroup creates a new group of given processes. The group is not known to any plugin, use
+// Plugin.NewProcessGroup to have it stopped as a unit during shutdown.
+func NewProcessGroup(name string, members ...ProcessInstance) *ProcessGroup {
+	return &ProcessGroup{
+		name:    name,
+		members: members,
+	}
+}
+
+// NewProcessGroup creates a new group of given processes registered with the plugin. Registered groups
+// are stopped as units during shutdown (see RunUntilSignal).
+func (p *Plugin) NewProcessGroup(name string, members ...ProcessInstance) *ProcessGroup {
+	group := NewProcessGroup(name, members...)
+	p.processMu.Lock()
+	defer p.processMu.Unlock()
+	p.groups = append(p.groups, group)
+	return group
+}
+
+// listGroups returns a snapshot of all registered groups in order of creation
+func (p *Plugin) listGroups() []*ProcessGroup {
+	p.processMu.RLock()
+	defer p.processMu.RUnlock()
+	return append([]*ProcessGroup(nil), p.groups...)
+}
+
+// GetName returns group name
+func (g *ProcessGroup) GetName() string {
+	return g.name
+}
+
+// GetMembers returns all group members in order
+func (g *ProcessGroup) GetMembers() []ProcessInstance {
+	return g.members
+}
+
+// Start starts group members one at a time in order. Every member must become healthy (see WaitHealthy)
+// before the next one is started, so later members can depend on earlier ones. If a member fails to start
+// or become healthy, or the context is cancelled, remaining members are not started.
+func (g *ProcessGroup) Start(ctx context.Context) error {
+	for i, member := range g.members {
+		if !member.IsAlive() {
+			if err := member.Start(); err != nil {
+				return errors.Errorf("start of group %s aborted, member %s (%d/%d) failed: %v",
+					g.name, member.GetName(), i+1, len(g.members), err)
+			}
+		}
+		if err := member.WaitHealthy(ctx); err != nil {
+			return errors.Errorf("start of group %s aborted, member %s (%d/%d) is not healthy: %v",
+				g.name, member.GetName(), i+1, len(g.members), err)
+		}
+	}
+	return nil
+}
+
+// RollingRestart restarts group members one at a time in order. Every member must become ready (see
+// WithReadinessProbe) before the next one is restarted. If a member fails to come back, or the context is
+// cancelled, the procedure is aborted and remaining members are left running.
+func (g *ProcessGroup) RollingRestart(ctx context.Context) error {
+	for i, member := range g.members {
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("rolling restart of group %s aborted before member %s (%d/%d): %v",
+				g.name, member.GetName(), i+1, len(g.members), ctx.Err())
+		default:
+		}
+		if err := member.Restart(); err != nil {
+			return errors.Errorf("rolling restart of group %s aborted, member %s (%d/%d) failed: %v",
+				g.name, member.GetName(), i+1, len(g.members), err)
+		}
+		if !member.IsReady() {
+			return errors.Errorf("rolling restart of group %s aborted, member %s (%d/%d) is not ready",
+				g.name, member.GetName(), i+1, len(g.members))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/unrolled/render"
+)
+
+// Variable names in process manager URLs
+const (
+	processVarName   = "name"
+	operationVarName = "operation"
+)
+
+// Process operations supported by the HTTP API
+const (
+	operationStart   = "start"
+	operationStop    = "stop"
+	operationRestart = "restart"
+)
+
+// AfterInit registers HTTP handlers (if HTTP is available):
+// - List all known processes:
+//   > curl -X GET http://localhost:<port>/processes
+// - Start, stop or restart a process:
+//   > curl -X POST http://localhost:<port>/processes/<name>/start|stop|restart
+func (p *Plugin) AfterInit() error {
+	if p.HTTP != nil {
+		p.HTTP.RegisterHTTPHandler("/processes", p.listProcessesHandler, "GET")
+		p.HTTP.RegisterHTTPHandler(fmt.Sprintf("/processes/{%s}/{%s:start|stop|restart}",
+			processVarName, operationVarName), p.processOperationHandler, "POST")
+	}
+	return nil
+}
+
+// listProcessesHandler processes requests to list all known processes
+func (p *Plugin) listProcessesHandler(formatter *render.Render) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		processes := p.listProcesses()
+		infos := make([]ProcessInfo, 0, len(processes))
+		for _, pr := range processes {
+			infos = append(infos, pr.GetInfo())
+		}
+		formatter.JSON(w, http.StatusOK, infos)
+	}
+}
+
+// processOperationHandler processes requests to start, stop or restart a process
+func (p *Plugin) processOperationHandler(formatter *render.Render) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		pr := p.getProcess(vars[processVarName])
+		if pr == nil {
+			formatter.JSON(w, http.StatusNotFound,
+				struct{ Error string }{fmt.Sprintf("process %q not found", vars[processVarName])})
+			return
+		}
+		code, err := pr.runOperation(vars[operationVarName])
+		if err != nil {
+			formatter.JSON(w, code, struct{ Error string }{err.Error()})
+			return
+		}
+		formatter.JSON(w, http.StatusOK, pr.GetInfo())
+	}
+}
+
+// runOperation runs the operation on the process and returns the HTTP status code describing the result.
+// Operations on the same process are serialized and rejected while the process is being restarted.
+func (p *Process) runOperation(operation string) (int, error) {
+	p.opMx.Lock()
+	defer p.opMx.Unlock()
+
+	if p.isRestarting() {
+		return http.StatusConflict, fmt.Errorf("process %s is being restarted", p.name)
+	}
+	var err error
+	switch operation {
+	case operationStart:
+		if p.isAlive() {
+			return http.StatusConflict, fmt.Errorf("process %s is already running", p.name)
+		}
+		err = p.Start()
+	case operationStop:
+		if !p.isAlive() {
+			return http.StatusConflict, fmt.Errorf("process %s is not running", p.name)
+		}
+		_, err = p.StopAndWait()
+	case operationRestart:
+		err = p.Restart()
+	default:
+		return http.StatusBadRequest, fmt.Errorf("unknown operation %q", operation)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"time"
+
+	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
+)
+
+// ProcessInfo is a snapshot of process state suitable for listing and reporting
+type ProcessInfo struct {
+	Name      string              `json:"name"`
+	Command   string              `json:"command,omitempty"`
+	Args      []string            `json:"args,omitempty"`
+	Pid       int                 `json:"pid,omitempty"`
+	Status    string              `json:"status"`
+	Running   bool                `json:"running"`
+	Ready     bool                `json:"ready"`
+	StartTime time.Time           `json:"start_time,omitempty"`
+	Labels    map[string]string   `json:"labels,omitempty"`
+	LastExit  *ExitClassification `json:"last_exit,omitempty"`
+	FlapScore float64             `json:"flap_score,omitempty"`
+}
+
+// GetLabels returns labels assigned to the process with WithLabels option
+func (p *Process) GetLabels() map[string]string {
+	if p.options == nil || p.options.labels == nil {
+		return map[string]string{}
+	}
+	labels := make(map[string]string, len(p.options.labels))
+	for k, v := range p.options.labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// GetInfo returns snapshot of the process state
+func (p *Process) GetInfo() ProcessInfo {
+	pid, running := p.Pid()
+	return ProcessInfo{
+		Name:      p.name,
+		Command:   p.GetCommand(),
+		Args:      p.GetArguments(),
+		Pid:       pid,
+		Status:    string(p.currentStatus()),
+		Running:   running && p.isAlive(),
+		Ready:     p.IsReady(),
+		StartTime: p.GetStartTime(),
+		Labels:    p.GetLabels(),
+		LastExit:  p.LastExit(),
+		FlapScore: p.FlapScore(),
+	}
+}
+
+// ListByLabel returns all processes labeled with given key and value
+func (p *Plugin) ListByLabel(key, value string) []ProcessInstance {
+	var processes []ProcessInstance
+	for _, pr := range p.listProcesses() {
+		if pr.options == nil {
+			continue
+		}
+		if v, ok := pr.options.labels[key]; ok && v == value {
+			processes = append(processes, pr)
+		}
+	}
+	return processes
+}
+
+// currentStatus returns status of the process as seen by the plugin
+func (p *Process) currentStatus() status.ProcessStatus {
+	switch {
+	case !p.wasStarted():
+		if p.status != nil && p.status.State != "" {
+			return p.status.State
+		}
+		return status.Initial
+	case p.isAlive() && !p.IsReady():
+		return status.Starting
+	case p.isAlive():
+		return status.Running
+	default:
+		return status.Terminated
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
+)
+
+// defaultNotifyBuffer is the default number of buffered notifications
+const defaultNotifyBuffer = 16
+
+// DroppedNotifications returns number of status notifications dropped because the consumer of the notification
+// channel did not keep up
+func (p *Process) DroppedNotifications() uint64 {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.droppedNotifications
+}
+
+// startNotifier prepares the notification buffer and starts delivering buffered notifications
+// to the notification channel
+func (p *Process) startNotifier() {
+	if p.GetNotificationChan() == nil {
+		p.notifyBuf = nil
+		return
+	}
+	size := p.options.notifyBuffer
+	if size <= 0 {
+		size = defaultNotifyBuffer
+	}
+	p.notifyBuf = make(chan status.ProcessStatus, size)
+	go p.deliverNotifications(p.notifyBuf, p.options.notifyChan, p.cancelChan)
+}
+
+// notify queues the notification without blocking. If the buffer is full, the notification is dropped.
+func (p *Process) notify(current status.ProcessStatus) {
+	if p.notifyBuf == nil {
+		return
+	}
+	select {
+	case p.notifyBuf <- current:
+	default:
+		p.mx.Lock()
+		p.droppedNotifications++
+		p.mx.Unlock()
+		p.log.Warnf("Notification %q of process %s dropped, consumer does not keep up", current, p.name)
+	}
+}
+
+// deliverNotifications forwards buffered notifications to the notification channel until the watcher is cancelled,
+// then it closes the channel
+func (p *Process) deliverNotifications(buf <-chan status.ProcessStatus, notifyChan chan status.ProcessStatus, cancel <-chan struct{}) {
+	defer p.closeNotifyChan(notifyChan)
+	for {
+		select {
+		case current := <-buf:
+			select {
+			case notifyChan <- current:
+			case <-cancel:
+				return
+			}
+		case <-cancel:
+			return
+		}
+	}
+}
+
+func (p *Process) closeNotifyChan(notifyChan chan status.ProcessStatus) {
+	// rescue wheel if somebody forgets to read the doc
+	defer func() {
+		if r := recover(); r != nil {
+			p.log.Warnf("notify channel should not be closed by provider (recovered from panic: %v)", r)
+		}
+	}()
+	close(notifyChan)
+}
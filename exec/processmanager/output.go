@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+// Output stream names
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+)
+
+// maxOutputLine is the maximum length of the output line, longer lines are split
+const maxOutputLine = 64 * 1024
+
+// OutputLine is a single line of the process output
+type OutputLine struct {
+	// Stream is either Stdout or Stderr
+	Stream string
+	// Text of the line without the trailing newline
+	Text string
+	// Time when the line was fully read from the pipe, set only with WithOutputTimestamps. Since the process
+	// may buffer its output, it can differ from the time the line was produced.
+	Time time.Time
+}
+
+// Watch output (either standard or custom). Terminates with process, since io.Copy reaches EOF.
+// If output is processed by lines (output handler or timestamps are set), lines are passed to the handler
+// and written to the writer (prefixed with the timestamp, if enabled).
+func (p *Process) watchOutput(stream string, w io.Writer, r io.Reader) {
+	if p.options.outputHandler == nil && !p.options.outputTimestamps {
+		go func() {
+			if _, err := io.Copy(w, r); err != nil {
+				p.log.Errorf("Output watcher error: %v", err)
+			}
+		}()
+		return
+	}
+	go func() {
+		reader := bufio.NewReaderSize(r, maxOutputLine)
+		for {
+			data, isPrefix, err := reader.ReadLine()
+			if len(data) > 0 || (err == nil && !isPrefix) {
+				line := OutputLine{Stream: stream, Text: string(data)}
+				if p.options.outputTimestamps {
+					line.Time = time.Now()
+				}
+				p.writeOutputLine(w, line)
+			}
+			if err != nil {
+				if err != io.EOF {
+					p.log.Errorf("Output watcher error: %v", err)
+				}
+				return
+			}
+		}
+	}()
+}
+
+func (p *Process) writeOutputLine(w io.Writer, line OutputLine) {
+	if p.options.outputHandler != nil {
+		p.runHook(func() {
+			p.options.outputHandler(line)
+		})
+	}
+	if w == nil {
+		return
+	}
+	text := line.Text + "\n"
+	if !line.Time.IsZero() {
+		text = line.Time.Format(time.RFC3339Nano) + " " + text
+	}
+	if _, err := io.WriteString(w, text); err != nil {
+		p.log.Errorf("Output watcher error: %v", err)
+	}
+}
@@ -15,9 +15,11 @@
 package processmanager
 
 import (
+	"context"
 	"io"
 	"os"
 	"os/exec"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -26,6 +28,7 @@ import (
 	"go.ligato.io/cn-infra/v2/exec/processmanager/template"
 	"go.ligato.io/cn-infra/v2/exec/processmanager/template/model/process"
 	"go.ligato.io/cn-infra/v2/infra"
+	"go.ligato.io/cn-infra/v2/rpc/rest"
 )
 
 // ProcessManager defines methods to create, delete or manage processes
@@ -44,9 +47,21 @@ type ProcessManager interface {
 	GetProcessByPID(pid int) ProcessInstance
 	// GetAllProcesses returns all processes known to plugin
 	GetAllProcesses() []ProcessInstance
+	// ListByLabel returns all processes labeled with given key and value
+	ListByLabel(key, value string) []ProcessInstance
 	// Delete removes process from the memory. Delete cancels process watcher, but does not stop the running instance
 	// (possible to attach later). Note: no process-related templates are removed
 	Delete(name string) error
+	// Events returns a channel receiving events about transitions of all processes
+	Events() <-chan ProcessEvent
+	// NewProcessGroup creates a group of processes registered with the plugin, so that it is stopped as a unit
+	// during shutdown
+	NewProcessGroup(name string, members ...ProcessInstance) *ProcessGroup
+	// RunUntilSignal blocks until one of the signals is received (SIGINT and SIGTERM by default) or the context
+	// is done, then stops all processes. A second signal kills remaining processes.
+	RunUntilSignal(ctx context.Context, sigs ...os.Signal) error
+	// Reconcile applies the minimal set of changes (start, stop, restart) needed to match provided specs
+	Reconcile(specs []ProcessSpec) ReconcileResult
 	// GetTemplate returns process template object with given name fom provided path. Returns nil if does not exists
 	// or error if the reader is not available
 	GetTemplate(name string) (*process.Template, error)
@@ -60,8 +75,13 @@ type ProcessManager interface {
 type Plugin struct {
 	// Reader handles process templates (optional, can be nil)
 	tReader *template.Reader
-	// All known process instances
+	// All known process instances and groups registered with the plugin
 	processes []*Process
+	groups    []*ProcessGroup
+	processMu sync.RWMutex
+	// Aggregated events of all processes, created on first use
+	events   *eventStream
+	eventsMu sync.Mutex
 
 	Deps
 }
@@ -69,6 +89,8 @@ type Plugin struct {
 // Deps define process dependencies
 type Deps struct {
 	infra.PluginDeps
+	// HTTP is optional, if set, the plugin exposes HTTP API to list and control processes
+	HTTP rest.HTTPHandlers
 }
 
 // Config contains information about the path where process templates are stored
@@ -112,7 +134,7 @@ func (p *Plugin) Init() error {
 // Close stops all process watcher. Processes are either kept running (if detached) or terminated automatically
 // if thay are child processes of the application
 func (p *Plugin) Close() error {
-	for _, pr := range p.processes {
+	for _, pr := range p.listProcesses() {
 		if pr.cancelChan != nil {
 			close(pr.cancelChan)
 		}
@@ -138,12 +160,14 @@ func (p *Plugin) AttachProcess(name string, cmd string, pid int, options ...POpt
 		options:    &POptions{},
 		command:    &exec.Cmd{Process: pr},
 		sh:         &status.Reader{Log: p.Log},
+		ready:      true,
+		pid:        pr.Pid,
 		cancelChan: make(chan struct{}),
 	}
 	for _, option := range options {
 		option(attachedPr.options)
 	}
-	p.processes = append(p.processes, attachedPr)
+	p.addProcess(attachedPr)
 
 	attachedPr.status, err = attachedPr.sh.ReadStatusFromPID(attachedPr.GetPid())
 	if err != nil {
@@ -161,6 +185,18 @@ func (p *Plugin) AttachProcess(name string, cmd string, pid int, options ...POpt
 
 // NewProcess creates a new process and saves its template if required
 func (p *Plugin) NewProcess(name, cmd string, options ...POption) ProcessInstance {
+	newPr := p.newProcess(name, cmd, options...)
+	p.addProcess(newPr)
+
+	if newPr.options.template {
+		p.writeAsTemplate(newPr)
+	}
+
+	return newPr
+}
+
+// newProcess prepares a process instance without adding it to the plugin
+func (p *Plugin) newProcess(name, cmd string, options ...POption) *Process {
 	newPr := &Process{
 		log:     p.Log,
 		name:    name,
@@ -175,12 +211,9 @@ func (p *Plugin) NewProcess(name, cmd string, options ...POption) ProcessInstanc
 	for _, option := range options {
 		option(newPr.options)
 	}
-	p.processes = append(p.processes, newPr)
-
-	if newPr.options.template {
-		p.writeAsTemplate(newPr)
+	if newPr.options.startStopped {
+		newPr.status.State = status.NotStarted
 	}
-
 	return newPr
 }
 
@@ -191,16 +224,53 @@ func (p *Plugin) NewProcessFromTemplate(tmp *process.Template) ProcessInstance {
 		p.Log.Errorf("cannot create a process from template: %v", err)
 		return nil
 	}
-	p.processes = append(p.processes, newTmpPr)
+	p.addProcess(newTmpPr)
 
 	go newTmpPr.watch()
 
 	return newTmpPr
 }
 
+// addProcess stores the process, which joins the aggregated event stream
+func (p *Plugin) addProcess(pr *Process) {
+	pr.setEvents(p.eventStream())
+	p.processMu.Lock()
+	defer p.processMu.Unlock()
+	p.processes = append(p.processes, pr)
+}
+
+// replaceProcess swaps the old process for the new one at the same position. The watcher of the old process
+// is closed.
+func (p *Plugin) replaceProcess(oldPr, newPr *Process) error {
+	newPr.setEvents(p.eventStream())
+	p.processMu.Lock()
+	defer p.processMu.Unlock()
+	for i, pr := range p.processes {
+		if pr != oldPr {
+			continue
+		}
+		if err := oldPr.deleteProcess(); err != nil {
+			newPr.setEvents(nil)
+			return err
+		}
+		oldPr.setEvents(nil)
+		p.processes[i] = newPr
+		return nil
+	}
+	newPr.setEvents(nil)
+	return errors.Errorf("process %s not found", oldPr.name)
+}
+
+// listProcesses returns a snapshot of all known processes in order of creation
+func (p *Plugin) listProcesses() []*Process {
+	p.processMu.RLock()
+	defer p.processMu.RUnlock()
+	return append([]*Process(nil), p.processes...)
+}
+
 // GetProcessByName uses process name to find a desired instance
 func (p *Plugin) GetProcessByName(name string) ProcessInstance {
-	for _, pr := range p.processes {
+	for _, pr := range p.listProcesses() {
 		if pr.name == name {
 			return pr
 		}
@@ -210,7 +280,7 @@ func (p *Plugin) GetProcessByName(name string) ProcessInstance {
 
 // GetProcessByPID uses process ID to find a desired instance
 func (p *Plugin) GetProcessByPID(pid int) ProcessInstance {
-	for _, pr := range p.processes {
+	for _, pr := range p.listProcesses() {
 		if pr.status.Pid == pid {
 			return pr
 		}
@@ -221,7 +291,7 @@ func (p *Plugin) GetProcessByPID(pid int) ProcessInstance {
 // GetAllProcesses returns all processes known to plugin
 func (p *Plugin) GetAllProcesses() []ProcessInstance {
 	var processes []ProcessInstance
-	for _, pr := range p.processes {
+	for _, pr := range p.listProcesses() {
 		processes = append(processes, pr)
 	}
 	return processes
@@ -229,12 +299,15 @@ func (p *Plugin) GetAllProcesses() []ProcessInstance {
 
 // Delete releases the process resources and removes it from the plugin cache
 func (p *Plugin) Delete(name string) error {
+	p.processMu.Lock()
+	defer p.processMu.Unlock()
 	var updated []*Process
 	for _, pr := range p.processes {
 		if pr.name == name {
 			if err := pr.deleteProcess(); err != nil {
 				return err
 			}
+			pr.setEvents(nil)
 		} else {
 			updated = append(updated, pr)
 		}
@@ -15,13 +15,28 @@
 package processmanager_test
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
 	. "github.com/onsi/gomega"
+	"github.com/unrolled/render"
 
 	"go.ligato.io/cn-infra/v2/exec/processmanager"
 	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
 	tmpModel "go.ligato.io/cn-infra/v2/exec/processmanager/template/model/process"
+	"go.ligato.io/cn-infra/v2/rpc/rest"
+	access "go.ligato.io/cn-infra/v2/rpc/rest/security/model/access-security"
 )
 
 func TestNewProcess(t *testing.T) {
@@ -107,3 +122,1052 @@ func TestNewProcessFromTemplate(t *testing.T) {
 	Expect(plugin.GetProcessByName("name")).To(BeNil())
 	Expect(plugin.GetAllProcesses()).To(HaveLen(0))
 }
+
+func TestReconcile(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	specs := []processmanager.ProcessSpec{
+		{Name: "first", Cmd: "/bin/sleep", Options: []processmanager.POption{processmanager.Args("10")}},
+		{Name: "second", Cmd: "/bin/sleep", Options: []processmanager.POption{processmanager.Args("10")}},
+	}
+	result := plugin.Reconcile(specs)
+	Expect(result.Failed).To(BeEmpty())
+	Expect(result.Started).To(ConsistOf("first", "second"))
+
+	specs[1].Options = []processmanager.POption{processmanager.Args("20")}
+	result = plugin.Reconcile(specs)
+	Expect(result.Failed).To(BeEmpty())
+	Expect(result.Unchanged).To(ConsistOf("first"))
+	Expect(result.Restarted).To(ConsistOf("second"))
+	Expect(plugin.GetProcessByName("second").GetArguments()).To(Equal([]string{"20"}))
+	Expect(plugin.GetProcessByName("second").IsAlive()).To(BeTrue())
+
+	_, err := plugin.GetProcessByName("first").StopAndWait()
+	Expect(err).To(BeNil())
+	result = plugin.Reconcile(specs)
+	Expect(result.Failed).To(BeEmpty())
+	Expect(result.Started).To(ConsistOf("first"))
+	Expect(result.Unchanged).To(ConsistOf("second"))
+	Expect(plugin.GetProcessByName("first").IsAlive()).To(BeTrue())
+
+	result = plugin.Reconcile(specs[1:])
+	Expect(result.Failed).To(BeEmpty())
+	Expect(result.Stopped).To(ConsistOf("first"))
+	Expect(result.Unchanged).To(ConsistOf("second"))
+	Expect(plugin.GetProcessByName("first")).To(BeNil())
+
+	result = plugin.Reconcile(nil)
+	Expect(result.Stopped).To(ConsistOf("second"))
+	Expect(plugin.GetAllProcesses()).To(BeEmpty())
+}
+
+func TestReadinessProbe(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	dir, err := ioutil.TempDir("", "pm-readiness")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	readyFile := filepath.Join(dir, "ready")
+
+	pr := plugin.NewProcess("probed", "/bin/sh",
+		processmanager.Args("-c", "sleep 0.3; touch "+readyFile+"; sleep 10"),
+		processmanager.WithReadinessProbe(processmanager.ReadinessProbe{
+			File:    readyFile,
+			Timeout: 5 * time.Second,
+		}))
+	Expect(pr.IsReady()).To(BeFalse())
+	Expect(pr.Start()).To(Succeed())
+	Expect(pr.IsReady()).To(BeTrue())
+	Expect(pr.Kill()).To(Succeed())
+
+	failing := plugin.NewProcess("failing", "/bin/sleep", processmanager.Args("10"),
+		processmanager.WithReadinessProbe(processmanager.ReadinessProbe{
+			File:    filepath.Join(dir, "missing"),
+			Timeout: 300 * time.Millisecond,
+		}))
+	Expect(failing.Start()).ToNot(Succeed())
+	Expect(failing.IsReady()).To(BeFalse())
+	Expect(failing.Kill()).To(Succeed())
+}
+
+func TestAuditEvent(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	events := make(chan *processmanager.AuditEvent, 1)
+	pr := plugin.NewProcess("audited", "/bin/sleep", processmanager.Args("10"),
+		processmanager.EnvVar([]string{"SECRET=password123"}),
+		processmanager.WithAuditHook(func(event *processmanager.AuditEvent) {
+			events <- event
+		}))
+	Expect(pr.Start()).To(Succeed())
+	defer pr.Kill()
+
+	var event *processmanager.AuditEvent
+	Eventually(events).Should(Receive(&event))
+	Expect(event.Name).To(Equal("audited"))
+	Expect(filepath.IsAbs(event.Path)).To(BeTrue())
+	Expect(event.Args).To(Equal([]string{"10"}))
+	Expect(event.Env).To(Equal([]string{"SECRET"}))
+	Expect(event.Pid).To(Equal(pr.GetPid()))
+
+	// panicking hook does not prevent the process from starting
+	panicking := plugin.NewProcess("audited-panic", "/bin/sleep", processmanager.Args("10"),
+		processmanager.WithAuditHook(func(event *processmanager.AuditEvent) {
+			panic("hook failure")
+		}))
+	Expect(panicking.Start()).To(Succeed())
+	defer panicking.Kill()
+	Expect(panicking.IsAlive()).To(BeTrue())
+}
+
+func TestRollingRestart(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	first := plugin.NewProcess("first", "/bin/sleep", processmanager.Args("10"))
+	second := plugin.NewProcess("second", "/bin/sleep", processmanager.Args("10"))
+	Expect(first.Start()).To(Succeed())
+	Expect(second.Start()).To(Succeed())
+	defer first.Kill()
+	defer second.Kill()
+	firstPid, secondPid := first.GetPid(), second.GetPid()
+
+	group := processmanager.NewProcessGroup("replicas", first, second)
+	Expect(group.RollingRestart(context.Background())).To(Succeed())
+	Expect(first.GetPid()).ToNot(Equal(firstPid))
+	Expect(second.GetPid()).ToNot(Equal(secondPid))
+	Expect(first.IsAlive()).To(BeTrue())
+	Expect(second.IsAlive()).To(BeTrue())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Expect(group.RollingRestart(ctx)).ToNot(Succeed())
+}
+
+func TestPreStopCommand(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	dir, err := ioutil.TempDir("", "pm-prestop")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "marker")
+
+	pr := plugin.NewProcess("prestop", "/bin/sleep", processmanager.Args("10"),
+		processmanager.WithPreStopCommand([]string{"/bin/sh", "-c", "touch " + marker}, time.Second))
+	Expect(pr.Start()).To(Succeed())
+	_, err = pr.StopAndWait()
+	Expect(err).To(BeNil())
+	Expect(marker).To(BeAnExistingFile())
+
+	// timed out drain command must not block the stop
+	slow := plugin.NewProcess("prestop-slow", "/bin/sleep", processmanager.Args("10"),
+		processmanager.WithPreStopCommand([]string{"/bin/sleep", "10"}, 100*time.Millisecond))
+	Expect(slow.Start()).To(Succeed())
+	start := time.Now()
+	_, err = slow.StopAndWait()
+	Expect(err).To(BeNil())
+	Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	Expect(slow.IsAlive()).To(BeFalse())
+}
+
+func TestRestartSchedule(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.Local)
+	Expect(processmanager.TimeWindow{Start: 11 * time.Hour, End: 13 * time.Hour}.Contains(now)).To(BeTrue())
+	Expect(processmanager.TimeWindow{Start: 22 * time.Hour, End: 4 * time.Hour}.Contains(now)).To(BeFalse())
+	Expect(processmanager.TimeWindow{Start: 22 * time.Hour, End: 4 * time.Hour}.Contains(now.Add(-10 * time.Hour))).To(BeTrue())
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	// window which certainly does not contain the current time
+	start := time.Duration(time.Now().Add(2*time.Hour).Hour()) * time.Hour
+	notifyChan := make(chan status.ProcessStatus, 10)
+	pr := plugin.NewProcess("scheduled", "/bin/sleep", processmanager.Args("0.1"),
+		processmanager.Restarts(1), processmanager.AutoTerminate(), processmanager.Notify(notifyChan),
+		processmanager.WithRestartSchedule([]processmanager.TimeWindow{{Start: start, End: start + time.Hour}}))
+	Expect(pr.Start()).To(Succeed())
+
+	Eventually(notifyChan, 5*time.Second).Should(Receive(Equal(status.ProcessStatus(status.RestartDeferred))))
+	Expect(pr.IsAlive()).To(BeFalse())
+}
+
+func TestPid(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("pid", "/bin/sleep", processmanager.Args("10"))
+	_, ok := pr.Pid()
+	Expect(ok).To(BeFalse())
+
+	Expect(pr.Start()).To(Succeed())
+	pid, ok := pr.Pid()
+	Expect(ok).To(BeTrue())
+	Expect(pid).To(Equal(pr.GetPid()))
+
+	Expect(pr.Restart()).To(Succeed())
+	newPid, ok := pr.Pid()
+	Expect(ok).To(BeTrue())
+	Expect(newPid).ToNot(Equal(pid))
+
+	_, err := pr.StopAndWait()
+	Expect(err).To(BeNil())
+	_, ok = pr.Pid()
+	Expect(ok).To(BeFalse())
+}
+
+func TestNotificationsDoNotBlockWatcher(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	// nobody reads the channel
+	notifyChan := make(chan status.ProcessStatus)
+	pr := plugin.NewProcess("slow-consumer", "/bin/sleep", processmanager.Args("0.1"),
+		processmanager.Restarts(2), processmanager.AutoTerminate(),
+		processmanager.Notify(notifyChan), processmanager.WithNotifyBuffer(1))
+	Expect(pr.Start()).To(Succeed())
+	firstPid := pr.GetPid()
+
+	// the watcher keeps restarting the process even though notifications are not consumed
+	Eventually(pr.GetPid, 5*time.Second, 100*time.Millisecond).ShouldNot(Equal(firstPid))
+	Eventually(pr.DroppedNotifications, 5*time.Second, 100*time.Millisecond).Should(BeNumerically(">", 0))
+}
+
+func TestSubscriberDropsCountedSeparately(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("slow-subscriber", "/bin/sleep", processmanager.Args("0.01"),
+		processmanager.Restarts(40), processmanager.AutoTerminate(),
+		processmanager.WithAdaptivePoll(10*time.Millisecond, 10*time.Millisecond))
+	// nobody reads the subscription
+	pr.On(status.Terminated)
+	Expect(pr.Start()).To(Succeed())
+
+	Eventually(pr.DroppedEvents, 10*time.Second, 100*time.Millisecond).Should(BeNumerically(">", 0))
+	Expect(pr.DroppedNotifications()).To(BeZero())
+}
+
+func TestListByLabel(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	db := plugin.NewProcess("db", "/bin/sleep", processmanager.Args("10"),
+		processmanager.WithLabels(map[string]string{"role": "database"}))
+	plugin.NewProcess("web", "/bin/sleep", processmanager.Args("10"),
+		processmanager.WithLabels(map[string]string{"role": "frontend"}))
+	plugin.NewProcess("other", "/bin/sleep", processmanager.Args("10"))
+
+	databases := plugin.ListByLabel("role", "database")
+	Expect(databases).To(HaveLen(1))
+	Expect(databases[0].GetName()).To(Equal("db"))
+	Expect(plugin.ListByLabel("role", "cache")).To(BeEmpty())
+
+	Expect(db.Start()).To(Succeed())
+	defer db.Kill()
+	info := db.GetInfo()
+	Expect(info.Name).To(Equal("db"))
+	Expect(info.Running).To(BeTrue())
+	Expect(info.Pid).To(Equal(db.GetPid()))
+	Expect(info.Labels).To(Equal(map[string]string{"role": "database"}))
+}
+
+// httpHandlers registers handlers directly into gorilla mux
+type httpHandlers struct {
+	router *mux.Router
+}
+
+func (h *httpHandlers) RegisterHTTPHandler(path string, provider rest.HandlerProvider, methods ...string) *mux.Route {
+	return h.router.HandleFunc(path, provider(render.New())).Methods(methods...)
+}
+
+func (h *httpHandlers) RegisterPermissionGroup(group ...*access.PermissionGroup) {}
+
+func (h *httpHandlers) GetPort() int { return 0 }
+
+func TestHTTPOperations(t *testing.T) {
+	RegisterTestingT(t)
+
+	handlers := &httpHandlers{router: mux.NewRouter()}
+	plugin := processmanager.NewPlugin(processmanager.UseDeps(func(deps *processmanager.Deps) {
+		deps.HTTP = handlers
+	}))
+	Expect(plugin.AfterInit()).To(Succeed())
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("http", "/bin/sleep", processmanager.Args("10"), processmanager.Restarts(5),
+		processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond))
+	defer pr.Kill()
+
+	// listing is safe while processes are added and removed
+	listed := make(chan struct{})
+	go func() {
+		defer close(listed)
+		for i := 0; i < 20; i++ {
+			recorder := httptest.NewRecorder()
+			handlers.router.ServeHTTP(recorder, httptest.NewRequest("GET", "/processes", nil))
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("http-%d", i)
+		plugin.NewProcess(name, "/bin/sleep", processmanager.Args("10"))
+		Expect(plugin.Delete(name)).To(Succeed())
+	}
+	<-listed
+
+	request := func(operation string) int {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/processes/"+operation, nil)
+		handlers.router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	Expect(request("unknown/start")).To(Equal(http.StatusNotFound))
+	Expect(request("http/start")).To(Equal(http.StatusOK))
+	Expect(pr.IsAlive()).To(BeTrue())
+	Expect(request("http/start")).To(Equal(http.StatusConflict))
+	Expect(request("http/restart")).To(Equal(http.StatusOK))
+	Expect(request("http/stop")).To(Equal(http.StatusOK))
+	Expect(pr.IsAlive()).To(BeFalse())
+	// the process stopped by the operator is not restarted despite the restart policy
+	Consistently(pr.IsAlive, 300*time.Millisecond, 50*time.Millisecond).Should(BeFalse())
+	Expect(request("http/stop")).To(Equal(http.StatusConflict))
+}
+
+func TestStartStopped(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	result := plugin.Reconcile([]processmanager.ProcessSpec{{
+		Name:    "stopped",
+		Cmd:     "/bin/sleep",
+		Options: []processmanager.POption{processmanager.Args("10"), processmanager.Restarts(1), processmanager.WithStartStopped()},
+	}})
+	Expect(result.Created).To(ConsistOf("stopped"))
+	Expect(result.Started).To(BeEmpty())
+
+	pr := plugin.GetProcessByName("stopped")
+	Expect(pr.GetInfo().Status).To(Equal(status.NotStarted))
+	Consistently(pr.IsAlive, 1500*time.Millisecond).Should(BeFalse())
+
+	// changed spec must not start the process either
+	result = plugin.Reconcile([]processmanager.ProcessSpec{{
+		Name:    "stopped",
+		Cmd:     "/bin/sleep",
+		Options: []processmanager.POption{processmanager.Args("20"), processmanager.Restarts(1), processmanager.WithStartStopped()},
+	}})
+	Expect(result.Failed).To(BeEmpty())
+	Expect(result.Created).To(ConsistOf("stopped"))
+	Expect(result.Restarted).To(BeEmpty())
+
+	pr = plugin.GetProcessByName("stopped")
+	Expect(pr.GetArguments()).To(Equal([]string{"20"}))
+	Expect(pr.GetInfo().Status).To(Equal(status.NotStarted))
+	Consistently(pr.IsAlive, 500*time.Millisecond).Should(BeFalse())
+
+	Expect(pr.Start()).To(Succeed())
+	defer pr.Kill()
+	Expect(pr.IsAlive()).To(BeTrue())
+	Expect(pr.GetInfo().Status).To(Equal(status.Running))
+}
+
+func TestExtraFiles(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	r, w, err := os.Pipe()
+	Expect(err).To(BeNil())
+	defer r.Close()
+	defer w.Close()
+
+	pr := plugin.NewProcess("extra-files", "/bin/sh", processmanager.Args("-c", "echo hello >&3"),
+		processmanager.WithExtraFiles([]*os.File{w}))
+	Expect(pr.Start()).To(Succeed())
+	_, err = pr.Wait()
+	Expect(err).To(BeNil())
+
+	// the file must stay open for the next process instance
+	Expect(pr.Restart()).To(Succeed())
+	_, err = pr.Wait()
+	Expect(err).To(BeNil())
+
+	buf := make([]byte, 12)
+	_, err = io.ReadFull(r, buf)
+	Expect(err).To(BeNil())
+	Expect(string(buf)).To(Equal("hello\nhello\n"))
+}
+
+func TestAdaptivePoll(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	notifyChan := make(chan status.ProcessStatus, 10)
+	pr := plugin.NewProcess("adaptive-poll", "/bin/sleep", processmanager.Args("0.2"),
+		processmanager.AutoTerminate(), processmanager.Notify(notifyChan),
+		processmanager.WithAdaptivePoll(50*time.Millisecond, 5*time.Second))
+	Expect(pr.Start()).To(Succeed())
+
+	// early crash is detected well before the default one second interval
+	Eventually(notifyChan, 600*time.Millisecond).Should(Receive(Equal(status.ProcessStatus(status.Terminated))))
+}
+
+func TestWaitHealthy(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	dir, err := ioutil.TempDir("", "pm-healthy")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "marker")
+
+	pr := plugin.NewProcess("healthy", "/bin/sh", processmanager.Args("-c", "sleep 0.2; touch "+marker+"; sleep 10"),
+		processmanager.WithReadinessProbe(processmanager.ReadinessProbe{File: marker}), processmanager.WithStartStopped())
+	defer pr.Kill()
+
+	// not started yet
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	Expect(pr.WaitHealthy(ctx)).ToNot(Succeed())
+
+	go pr.Start()
+	Expect(pr.WaitHealthy(context.Background())).To(Succeed())
+	Expect(marker).To(BeAnExistingFile())
+
+	// terminated process never becomes healthy
+	failed := plugin.NewProcess("unhealthy", "/bin/sh", processmanager.Args("-c", "exit 1"),
+		processmanager.WithReadinessProbe(processmanager.ReadinessProbe{File: marker + "-never"}), processmanager.AutoTerminate())
+	Expect(failed.Start()).ToNot(Succeed())
+	Expect(failed.WaitHealthy(context.Background())).ToNot(Succeed())
+}
+
+func TestFDLimit(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("fd-count", "/bin/sleep", processmanager.Args("10"))
+	Expect(pr.Start()).To(Succeed())
+	defer pr.Kill()
+	// stdio only
+	Eventually(func() (int, error) {
+		return processmanager.CountOpenFiles(pr.GetPid())
+	}).Should(Equal(3))
+
+	// the limit is already reached by stdio of the started process
+	notifyChan := make(chan status.ProcessStatus, 10)
+	limited := plugin.NewProcess("fd-limited", "/bin/sleep", processmanager.Args("10"),
+		processmanager.Notify(notifyChan), processmanager.WithFDLimit(3),
+		processmanager.WithFDLimitRestart(), processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond))
+	Expect(limited.Start()).To(Succeed())
+	defer limited.Kill()
+	firstPid := limited.GetPid()
+
+	Eventually(notifyChan, 2*time.Second).Should(Receive(Equal(status.ProcessStatus(status.FDLimitWarning))))
+	Eventually(limited.GetPid, 2*time.Second, 50*time.Millisecond).ShouldNot(Equal(firstPid))
+}
+
+func TestLastExit(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("last-exit", "/bin/sleep", processmanager.Args("10"))
+	Expect(pr.LastExit()).To(BeNil())
+	Expect(pr.Start()).To(Succeed())
+	_, err := pr.StopAndWait()
+	Expect(err).To(BeNil())
+	Expect(pr.LastExit().Category).To(Equal(processmanager.ExitStopped))
+
+	Expect(pr.Start()).To(Succeed())
+	Expect(pr.Signal(syscall.SIGSEGV)).To(Succeed())
+	_, err = pr.Wait()
+	Expect(err).To(BeNil())
+	Expect(pr.GetInfo().LastExit.Category).To(Equal(processmanager.ExitCrashed))
+}
+
+func TestRestartDelays(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("restart-delays", "/bin/sleep", processmanager.Args("10"),
+		processmanager.Restarts(5), processmanager.AutoTerminate(),
+		processmanager.WithRestartDelays(processmanager.RestartDelays{CleanExit: time.Minute}),
+		processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond))
+	Expect(pr.Start()).To(Succeed())
+	defer pr.Kill()
+
+	// crashed process is restarted immediately
+	firstPid := pr.GetPid()
+	Expect(pr.Signal(syscall.SIGSEGV)).To(Succeed())
+	Eventually(pr.GetPid, time.Second, 50*time.Millisecond).ShouldNot(Equal(firstPid))
+	Eventually(pr.IsAlive).Should(BeTrue())
+
+	// process stopped by operator is not restarted
+	Expect(pr.Stop()).To(Succeed())
+	Eventually(pr.IsAlive).Should(BeFalse())
+	Consistently(pr.IsAlive, 500*time.Millisecond, 50*time.Millisecond).Should(BeFalse())
+}
+
+func TestShell(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	r, w, err := os.Pipe()
+	Expect(err).To(BeNil())
+	defer r.Close()
+	defer w.Close()
+
+	pr := plugin.NewProcess("shell", `echo "$0:$1" | tr a-z A-Z`, processmanager.Args("; touch injected"),
+		processmanager.WithShell(""), processmanager.Writer(w, nil))
+	Expect(pr.Start()).To(Succeed())
+	_, err = pr.Wait()
+	Expect(err).To(BeNil())
+
+	expected := "SHELL:; TOUCH INJECTED\n"
+	out := make([]byte, len(expected))
+	_, err = io.ReadFull(r, out)
+	Expect(err).To(BeNil())
+	Expect(string(out)).To(Equal(expected))
+	Expect("injected").NotTo(BeAnExistingFile())
+}
+
+func TestRunUntilSignal(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	first := plugin.NewProcess("first", "/bin/sleep", processmanager.Args("10"), processmanager.Restarts(5))
+	Expect(first.Start()).To(Succeed())
+	defer first.Kill()
+	// ignores the termination signal, so it has to be killed after the stop timeout
+	second := plugin.NewProcess("second", `trap "" TERM; sleep 10 & wait; sleep 10`,
+		processmanager.WithShell(""), processmanager.Restarts(5),
+		processmanager.WithStopTimeout(200*time.Millisecond))
+	Expect(second.Start()).To(Succeed())
+	defer second.Kill()
+	time.Sleep(100 * time.Millisecond)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- plugin.RunUntilSignal(context.Background(), syscall.SIGUSR1)
+	}()
+	Consistently(errCh, 200*time.Millisecond).ShouldNot(Receive())
+
+	Expect(syscall.Kill(os.Getpid(), syscall.SIGUSR1)).To(Succeed())
+	Eventually(errCh, 2*time.Second).Should(Receive(BeNil()))
+	Expect(first.IsAlive()).To(BeFalse())
+	Expect(second.IsAlive()).To(BeFalse())
+	Expect(second.LastExit()).NotTo(BeNil())
+	Expect(second.LastExit().Category).To(Equal(processmanager.ExitForceStopped))
+	Consistently(first.IsAlive, 500*time.Millisecond, 50*time.Millisecond).Should(BeFalse())
+}
+
+func TestShutdownStopsGroupsAsUnits(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	dir, err := ioutil.TempDir("", "pm-shutdown")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	stopped := filepath.Join(dir, "stopped")
+
+	// every process records its name when it receives the termination signal
+	var all []processmanager.ProcessInstance
+	newProcess := func(name string) processmanager.ProcessInstance {
+		pr := plugin.NewProcess(name, fmt.Sprintf(`trap "echo %s >> %s; exit 0" TERM; sleep 10 & wait`, name, stopped),
+			processmanager.WithShell(""))
+		all = append(all, pr)
+		return pr
+	}
+	worker := newProcess("worker")
+	newProcess("db")
+	app1 := newProcess("app-1")
+	app2 := newProcess("app-2")
+	plugin.NewProcessGroup("app", app1, app2)
+	plugin.NewProcessGroup("workers", worker)
+	for _, pr := range all {
+		Expect(pr.Start()).To(Succeed())
+		defer pr.Kill()
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- plugin.RunUntilSignal(context.Background(), syscall.SIGUSR1)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	Expect(syscall.Kill(os.Getpid(), syscall.SIGUSR1)).To(Succeed())
+	Eventually(errCh, 5*time.Second).Should(Receive(BeNil()))
+
+	// groups in reverse order of their creation, then remaining processes in reverse order of creation
+	data, err := ioutil.ReadFile(stopped)
+	Expect(err).To(BeNil())
+	Expect(strings.Fields(string(data))).To(Equal([]string{"worker", "app-2", "app-1", "db"}))
+}
+
+func TestOutputTimestamps(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	lines := make(chan processmanager.OutputLine, 10)
+	before := time.Now()
+	pr := plugin.NewProcess("output", "echo out; echo err >&2", processmanager.WithShell(""),
+		processmanager.WithOutputTimestamps(), processmanager.WithOutputHandler(func(line processmanager.OutputLine) {
+			lines <- line
+		}))
+	Expect(pr.Start()).To(Succeed())
+
+	received := map[string]processmanager.OutputLine{}
+	for i := 0; i < 2; i++ {
+		var line processmanager.OutputLine
+		Eventually(lines).Should(Receive(&line))
+		received[line.Stream] = line
+	}
+	Expect(received[processmanager.Stdout].Text).To(Equal("out"))
+	Expect(received[processmanager.Stderr].Text).To(Equal("err"))
+	for _, line := range received {
+		Expect(line.Time).To(BeTemporally(">=", before))
+		Expect(line.Time).To(BeTemporally("<=", time.Now()))
+	}
+}
+
+func TestOnState(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("on-state", "/bin/sleep", processmanager.Args("10"),
+		processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond))
+	first := pr.On(status.Terminated)
+	second := pr.On(status.Terminated)
+	sleeping := pr.On(status.Sleeping)
+	cancelled := pr.On(status.Terminated)
+	pr.Off(cancelled)
+	Expect(cancelled).To(BeClosed())
+
+	Expect(pr.Start()).To(Succeed())
+	pid := pr.GetPid()
+	var event processmanager.ProcessEvent
+	Eventually(sleeping, 2*time.Second).Should(Receive(&event))
+	Expect(event.Process).To(Equal("on-state"))
+	Expect(event.Pid).To(Equal(pid))
+	Expect(event.To).To(Equal(status.ProcessStatus(status.Sleeping)))
+
+	Expect(pr.Signal(syscall.SIGSEGV)).To(Succeed())
+	_, err := pr.Wait()
+	Expect(err).To(BeNil())
+	for _, ch := range []<-chan processmanager.ProcessEvent{first, second} {
+		Eventually(ch, 2*time.Second).Should(Receive(&event))
+		Expect(event.From).To(Equal(status.ProcessStatus(status.Sleeping)))
+		Expect(event.To).To(Equal(status.ProcessStatus(status.Terminated)))
+		Expect(event.Exit).NotTo(BeNil())
+		Expect(event.Exit.Category).To(Equal(processmanager.ExitCrashed))
+	}
+	Consistently(sleeping, 200*time.Millisecond).ShouldNot(Receive())
+
+	Expect(plugin.Delete("on-state")).To(Succeed())
+	Eventually(first).Should(BeClosed())
+}
+
+func TestOnCrash(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("crash", "sleep 0.2; exit 3", processmanager.WithShell(""),
+		processmanager.AutoTerminate(), processmanager.Restarts(1),
+		processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond),
+		processmanager.WithOnCrash([]string{"/bin/sh", "-c",
+			"echo $PM_PROCESS $PM_PID $PM_EXIT_CATEGORY $PM_EXIT_CODE"}, time.Second))
+	terminated := pr.On(status.Terminated)
+	Expect(pr.Start()).To(Succeed())
+	defer pr.Kill()
+	pid := pr.GetPid()
+
+	var event processmanager.ProcessEvent
+	Eventually(terminated, 2*time.Second).Should(Receive(&event))
+	Expect(event.Exit).NotTo(BeNil())
+	Expect(event.Diagnostics).To(Equal(fmt.Sprintf("crash %d failed 3\n", pid)))
+
+	// the process was restarted after the diagnostics
+	Eventually(pr.GetPid).ShouldNot(Equal(pid))
+}
+
+func TestSetRestartPolicy(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("restart-policy", "/bin/sleep", processmanager.Args("10"),
+		processmanager.AutoTerminate(), processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond))
+	Expect(pr.Start()).To(Succeed())
+	defer pr.Kill()
+
+	// restarts granted at runtime
+	pr.SetRestartPolicy(1)
+	pid := pr.GetPid()
+	Expect(pr.Signal(syscall.SIGSEGV)).To(Succeed())
+	Eventually(pr.GetPid, 2*time.Second, 50*time.Millisecond).ShouldNot(Equal(pid))
+	Eventually(pr.IsAlive).Should(BeTrue())
+
+	// budget is exhausted
+	pid = pr.GetPid()
+	Expect(pr.Signal(syscall.SIGSEGV)).To(Succeed())
+	Eventually(pr.IsAlive).Should(BeFalse())
+	Consistently(pr.GetPid, 300*time.Millisecond, 50*time.Millisecond).Should(Equal(pid))
+
+	// unlimited restarts
+	pr.SetRestartPolicy(-1)
+	Expect(pr.Start()).To(Succeed())
+	for i := 0; i < 2; i++ {
+		pid = pr.GetPid()
+		Expect(pr.Signal(syscall.SIGSEGV)).To(Succeed())
+		Eventually(pr.GetPid, 2*time.Second, 50*time.Millisecond).ShouldNot(Equal(pid))
+		Eventually(pr.IsAlive).Should(BeTrue())
+	}
+}
+
+func TestEvents(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	events := plugin.Events()
+	Expect(plugin.Events()).To(Equal(events))
+
+	poll := processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond)
+	first := plugin.NewProcess("first", "/bin/sleep", processmanager.Args("10"), poll)
+	Expect(first.Start()).To(Succeed())
+	defer first.Kill()
+	second := plugin.NewProcess("second", "/bin/sleep", processmanager.Args("10"), poll)
+	Expect(second.Start()).To(Succeed())
+	defer second.Kill()
+
+	sleeping := map[string]bool{}
+	Eventually(func() map[string]bool {
+		select {
+		case event := <-events:
+			if event.To == status.Sleeping {
+				sleeping[event.Process] = true
+			}
+		default:
+		}
+		return sleeping
+	}, 2*time.Second, 10*time.Millisecond).Should(Equal(map[string]bool{"first": true, "second": true}))
+
+	// deleted process does not contribute anymore
+	Expect(plugin.Delete("second")).To(Succeed())
+	Expect(second.Kill()).To(Succeed())
+	Expect(first.Signal(syscall.SIGSEGV)).To(Succeed())
+	_, err := first.Wait()
+	Expect(err).To(BeNil())
+	var event processmanager.ProcessEvent
+	Eventually(events, 2*time.Second).Should(Receive(&event))
+	Expect(event.Process).To(Equal("first"))
+	Expect(event.To).To(Equal(status.ProcessStatus(status.Terminated)))
+	Consistently(events, 300*time.Millisecond).ShouldNot(Receive())
+	Expect(plugin.DroppedEvents()).To(BeZero())
+}
+
+func TestLifecycleDeadline(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("deadline", "/bin/sleep", processmanager.Args("10"),
+		processmanager.Restarts(-1),
+		processmanager.WithLifecycleDeadline(300*time.Millisecond),
+		processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond))
+	exceeded := pr.On(status.DeadlineExceeded)
+	Expect(pr.Start()).To(Succeed())
+
+	var event processmanager.ProcessEvent
+	Eventually(exceeded, 5*time.Second).Should(Receive(&event))
+	Expect(event.From).To(Equal(status.ProcessStatus(status.Terminated)))
+	Expect(pr.IsAlive()).To(BeFalse())
+	// not restarted despite the infinite restart policy
+	Consistently(pr.IsAlive, 300*time.Millisecond).Should(BeFalse())
+	Expect(exceeded).NotTo(Receive())
+}
+
+func TestEnvFile(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	dir, err := ioutil.TempDir("", "pm-env")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	envFile := filepath.Join(dir, "env")
+	Expect(ioutil.WriteFile(envFile, []byte("GREETING=hello\n"), 0600)).To(Succeed())
+
+	lines := make(chan string, 10)
+	pr := plugin.NewProcess("env-file", `echo "$GREETING $OTHER"; sleep 10`, processmanager.WithShell(""),
+		processmanager.EnvVar([]string{"GREETING=explicit", "OTHER=kept"}),
+		processmanager.WithEnvFile(envFile),
+		processmanager.WithOutputHandler(func(line processmanager.OutputLine) {
+			lines <- line.Text
+		}))
+	Expect(pr.Start()).To(Succeed())
+	defer pr.Kill()
+	Eventually(lines).Should(Receive(Equal("hello kept")))
+
+	// the file is read again on restart
+	Expect(ioutil.WriteFile(envFile, []byte("GREETING='changed'\n"), 0600)).To(Succeed())
+	Expect(pr.Restart()).To(Succeed())
+	Eventually(lines).Should(Receive(Equal("changed kept")))
+}
+
+func TestReplace(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("replace", "/bin/sleep", processmanager.Args("10"),
+		processmanager.WithLabels(map[string]string{"role": "worker"}),
+		processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond))
+	terminated := pr.On(status.Terminated)
+	Expect(pr.Start()).To(Succeed())
+	defer pr.Kill()
+	pid := pr.GetPid()
+
+	// the old process keeps running if the new binary is invalid
+	Expect(pr.Replace("/nonexistent/binary", nil)).NotTo(Succeed())
+	Expect(pr.GetPid()).To(Equal(pid))
+	Expect(pr.IsAlive()).To(BeTrue())
+
+	Expect(pr.Replace("/bin/sh", []string{"-c", "sleep 10"})).To(Succeed())
+	Expect(pr.GetPid()).NotTo(Equal(pid))
+	Expect(pr.IsAlive()).To(BeTrue())
+	Expect(pr.GetCommand()).To(Equal("/bin/sh"))
+	Expect(pr.GetArguments()).To(Equal([]string{"-c", "sleep 10"}))
+	Expect(pr.GetLabels()).To(HaveKeyWithValue("role", "worker"))
+	Expect(pr.RestartHistory()).To(HaveLen(1))
+	// subscriptions are preserved
+	Expect(pr.Signal(syscall.SIGKILL)).To(Succeed())
+	_, err := pr.Wait()
+	Expect(err).To(BeNil())
+	Eventually(terminated, 2*time.Second).Should(Receive())
+}
+
+func TestStopWithoutRestartDelays(t *testing.T) {
+	RegisterTestingT(t)
+
+	plugin := processmanager.Plugin{}
+	plugin.PluginName = "test-pm"
+	plugin.PluginDeps.Setup()
+	defer func() {
+		err := plugin.Close()
+		Expect(err).To(BeNil())
+	}()
+
+	pr := plugin.NewProcess("stop-no-delays", "/bin/sleep", processmanager.Args("10"),
+		processmanager.Restarts(5), processmanager.AutoTerminate(),
+		processmanager.WithAdaptivePoll(50*time.Millisecond, 50*time.Millisecond))
+	Expect(pr.Start()).To(Succeed())
+	defer pr.Kill()
+
+	// process stopped by operator is not restarted, even though restarts are left
+	Expect(pr.Stop()).To(Succeed())
+	Eventually(pr.IsAlive).Should(BeFalse())
+	Consistently(pr.IsAlive, 500*time.Millisecond, 50*time.Millisecond).Should(BeFalse())
+
+	// crashed process is still restarted
+	Expect(pr.Start()).To(Succeed())
+	pid := pr.GetPid()
+	Expect(pr.Signal(syscall.SIGSEGV)).To(Succeed())
+	Eventually(pr.GetPid, time.Second, 50*time.Millisecond).ShouldNot(Equal(pid))
+	Eventually(pr.IsAlive).Should(BeTrue())
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"time"
+
+	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
+)
+
+// defaultPollInterval is the fixed interval the watcher polls the process status with
+const defaultPollInterval = 1 * time.Second
+
+// pollInterval computes the interval of the process watcher. In the adaptive mode (min < max), the interval
+// is doubled after every poll which found the process stable, up to max. Any problem resets it to min.
+type pollInterval struct {
+	min, max time.Duration
+	current  time.Duration
+}
+
+func newPollInterval(options *POptions) *pollInterval {
+	if options == nil || options.pollMin <= 0 {
+		return &pollInterval{min: defaultPollInterval, max: defaultPollInterval, current: defaultPollInterval}
+	}
+	max := options.pollMax
+	if max < options.pollMin {
+		max = options.pollMin
+	}
+	return &pollInterval{min: options.pollMin, max: max, current: options.pollMin}
+}
+
+// update adjusts the interval according to the result of the last poll and returns true if it changed
+func (pi *pollInterval) update(stable bool) bool {
+	prev := pi.current
+	if stable {
+		pi.current *= 2
+		if pi.current > pi.max {
+			pi.current = pi.max
+		}
+	} else {
+		pi.current = pi.min
+	}
+	return pi.current != prev
+}
+
+// isStable returns false for states which require fast polling: the process is (re)starting, terminated
+// or its state cannot be read
+func isStable(current status.ProcessStatus) bool {
+	switch current {
+	case status.Terminated, status.Zombie, status.Unavailable, status.Starting, status.Initial:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollInterval(t *testing.T) {
+	fixed := newPollInterval(nil)
+	if fixed.update(true) || fixed.current != defaultPollInterval {
+		t.Errorf("expected fixed interval %v, got %v", defaultPollInterval, fixed.current)
+	}
+
+	pi := newPollInterval(&POptions{pollMin: 100 * time.Millisecond, pollMax: time.Second})
+	expected := []time.Duration{200, 400, 800, 1000, 1000}
+	for i, exp := range expected {
+		pi.update(true)
+		if pi.current != exp*time.Millisecond {
+			t.Errorf("poll %d: expected interval %v, got %v", i, exp*time.Millisecond, pi.current)
+		}
+	}
+	if !pi.update(false) || pi.current != 100*time.Millisecond {
+		t.Errorf("expected interval reset to minimum, got %v", pi.current)
+	}
+}
@@ -15,8 +15,10 @@
 package processmanager
 
 import (
+	"context"
 	"os"
 	"os/exec"
+	"sync"
 	"time"
 
 	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
@@ -39,6 +41,8 @@ type ProcessInstance interface {
 	Start() error
 	// Restart briefly stops and starts the process. If the process is not running, it is started.
 	Restart() error
+	// Replace changes command and arguments of the process and restarts it to run the new binary
+	Replace(cmd string, args []string) error
 	// Stop sends the termination signal to the process. The status is set to 'stopped' (or 'failed' if not successful).
 	// Attempt to stop a non-existing process instance results in error
 	Stop() error
@@ -54,14 +58,24 @@ type ProcessInstance interface {
 	Signal(signal os.Signal) error
 	// IsAlive returns true if process is alive, or false if not or if the inner instance does not exist.
 	IsAlive() bool
+	// IsReady returns true if process is alive and passed its readiness probe (if defined).
+	IsReady() bool
+	// WaitHealthy blocks until the process is alive and passes its readiness probe (if defined), or returns
+	// error if the process terminates or the context is done first.
+	WaitHealthy(ctx context.Context) error
 	// GetNotification returns channel to watch process availability/status.
 	GetNotificationChan() <-chan status.ProcessStatus
+	// DroppedNotifications returns number of notifications dropped because the consumer did not keep up
+	DroppedNotifications() uint64
 	// GetName returns process name
 	GetName() string
 	// GetInstanceName returns process name from status
 	GetInstanceName() string
 	// GetPid returns process ID, or zero if process instance does not exist
 	GetPid() int
+	// Pid returns process ID of the live process instance. The flag is false if the process was not started
+	// yet or it was already reaped.
+	Pid() (int, bool)
 	// GetStatus reads and returns all current plugin-defined process state data
 	GetStatus(pid int) (*status.File, error)
 	// GetCommand returns process command
@@ -72,12 +86,36 @@ type ProcessInstance interface {
 	GetStartTime() time.Time
 	// GetUptime returns time elapsed since the process started
 	GetUptime() time.Duration
+	// GetLabels returns labels assigned to the process
+	GetLabels() map[string]string
+	// GetInfo returns snapshot of the process state
+	GetInfo() ProcessInfo
+	// SetRestartPolicy replaces the remaining number of automatic restarts (-1 for unlimited)
+	SetRestartPolicy(restarts int32)
+	// FlapScore returns score between 0 and 1 summarizing how frequently the process restarted recently
+	FlapScore() float64
+	// RestartHistory returns times of the last restarts of the process
+	RestartHistory() []time.Time
+	// On returns a channel receiving events about transitions into the given state. Every call creates
+	// a new subscription, which can be cancelled with Off.
+	On(state status.ProcessStatus) <-chan ProcessEvent
+	// Off cancels the subscription created by On and closes its channel
+	Off(ch <-chan ProcessEvent)
+	// DroppedEvents returns number of events dropped because a subscriber created by On did not keep up
+	DroppedEvents() uint64
+	// LastExit returns classification of the last exit of the process, or nil if it did not exit yet
+	LastExit() *ExitClassification
 }
 
 // Process is wrapper around the os.Process
 type Process struct {
 	log logging.Logger
 
+	// Guards process fields shared with the watcher
+	mx sync.Mutex
+	// Serializes operations requested via HTTP API
+	opMx sync.Mutex
+
 	// Process identification name
 	name string
 
@@ -97,19 +135,60 @@ type Process struct {
 	// Prevents to start multiple watchers for one process
 	isWatched bool
 
+	// Set when the process passed its readiness probe
+	ready bool
+
+	// PID of the current process instance, zero if not started or reaped
+	pid int
+
+	// Set while the process is being restarted
+	restarting bool
+
+	// Set once the process was started for the first time
+	started bool
+
+	// Remaining number of automatic restarts, set from options when the watcher starts unless set
+	// with SetRestartPolicy before
+	restartsLeft int32
+	restartsSet  bool
+
+	// Times of the last restarts, the oldest first (see FlapScore)
+	restartTimes []time.Time
+
+	// Set when the process is stopped as part of the plugin shutdown, disables automatic restarts
+	shutdown bool
+
+	// Set once the lifecycle deadline (see WithLifecycleDeadline) elapsed, disables automatic restarts
+	deadlineExceeded bool
+
+	// Why the plugin stopped the current process instance, and how the last instance ended
+	stopReason StopReason
+	lastExit   *ExitClassification
+
+	// Buffer of notifications to be delivered to the notification channel
+	notifyBuf            chan status.ProcessStatus
+	droppedNotifications uint64
+
+	// Channels of subscriptions to transitions into particular states (see On)
+	subscriptions map[status.ProcessStatus][]chan ProcessEvent
+	droppedEvents uint64
+	// Aggregated event stream of the plugin (see Plugin.Events), nil once the process is deleted
+	events *eventStream
+
 	// Other process-related fields not included in status
 	cancelChan chan struct{}
 	startTime  time.Time
 }
 
-// Start a process with defined arguments. Every process is watched for liveness and status changes
+// Start a process with defined arguments. Every process is watched for liveness and status changes.
+// If the readiness probe is defined, Start blocks until the process is ready.
 func (p *Process) Start() (err error) {
-	if p.command, err = p.startProcess(); err != nil {
+	if err = p.startProcess(); err != nil {
 		return err
 	}
 	p.log.Debugf("New process %s was started (PID: %d)", p.GetName(), p.GetPid())
 
-	return nil
+	return p.waitReady()
 }
 
 // IsAlive checks whether the process is running sending zero signal. Only a simple check, does not return error
@@ -119,7 +198,11 @@ func (p *Process) IsAlive() bool {
 
 // Restart the process, or start it if it is not running
 func (p *Process) Restart() (err error) {
+	p.setRestarting(true)
+	defer p.setRestarting(false)
+
 	if p.isAlive() {
+		p.setStopReason(StopReasonRestart)
 		if _, err = p.StopAndWait(); err != nil {
 			p.log.Warnf("Cannot stop process %s due to error, trying force stop... (err: %v)", p.GetName(), err)
 			if err = p.forceStopProcess(); err != nil {
@@ -127,9 +210,23 @@ func (p *Process) Restart() (err error) {
 			}
 		}
 	}
-	p.command, err = p.startProcess()
+	if err = p.startProcess(); err != nil {
+		return err
+	}
 	p.log.Debugf("Process %s was restarted (PID: %d)", p.GetName(), p.GetPid())
-	return err
+	return p.waitReady()
+}
+
+func (p *Process) setRestarting(restarting bool) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.restarting = restarting
+}
+
+func (p *Process) isRestarting() bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.restarting
 }
 
 // Stop sends the SIGTERM signal to stop given process
@@ -185,19 +282,47 @@ func (p *Process) GetInstanceName() string {
 
 // GetPid returns process ID
 func (p *Process) GetPid() int {
-	if p.command != nil && p.command.Process != nil {
-		return p.command.Process.Pid
+	if proc := p.osProcess(); proc != nil {
+		return proc.Pid
 	}
 	return p.status.Pid
 }
 
+// Pid returns process ID of the current process instance. It returns false before the process is started
+// and after it terminated and was reaped, so the returned PID never belongs to another (reused) process.
+func (p *Process) Pid() (int, bool) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.pid, p.pid != 0
+}
+
+func (p *Process) setPid(pid int) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.pid = pid
+}
+
+// clearPid resets the PID only if it still belongs to given instance, so that the PID of the instance
+// started meanwhile (e.g. by the watcher) is not lost
+func (p *Process) clearPid(pid int) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.pid == pid {
+		p.pid = 0
+	}
+}
+
 // GetCommand returns command used to start process. May be empty for attached processes
 func (p *Process) GetCommand() string {
+	p.mx.Lock()
+	defer p.mx.Unlock()
 	return p.cmd
 }
 
 // GetArguments returns arguments process was started with, if any. May be empty also for attached processes
 func (p *Process) GetArguments() []string {
+	p.mx.Lock()
+	defer p.mx.Unlock()
 	if p.options.args == nil {
 		return []string{}
 	}
@@ -223,13 +348,60 @@ func (p *Process) GetNotificationChan() <-chan status.ProcessStatus {
 
 // GetStartTime returns process start timestamp
 func (p *Process) GetStartTime() time.Time {
+	p.mx.Lock()
+	defer p.mx.Unlock()
 	return p.startTime
 }
 
 // GetUptime returns process uptime since the last start
 func (p *Process) GetUptime() time.Duration {
-	if p.startTime.Nanosecond() == 0 {
+	startTime := p.GetStartTime()
+	if startTime.Nanosecond() == 0 {
 		return 0
 	}
-	return time.Since(p.startTime)
+	return time.Since(startTime)
+}
+
+// setCommand stores the command of the started process instance along with its PID and start time,
+// which is returned
+func (p *Process) setCommand(cmd *exec.Cmd) time.Time {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.command = cmd
+	p.pid = cmd.Process.Pid
+	p.startTime = time.Now()
+	return p.startTime
+}
+
+// osProcess returns the OS process of the current process instance, or nil if it does not exist
+func (p *Process) osProcess() *os.Process {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.command == nil {
+		return nil
+	}
+	return p.command.Process
+}
+
+// setWatched marks the process watcher as running or stopped, returns false if it already was in that state
+func (p *Process) setWatched(watched bool) bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if p.isWatched == watched {
+		return false
+	}
+	p.isWatched = watched
+	return true
+}
+
+func (p *Process) isWatching() bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.isWatched
+}
+
+func (p *Process) clearStartTime() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.startTime = time.Time{}
 }
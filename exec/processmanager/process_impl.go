@@ -15,7 +15,7 @@
 package processmanager
 
 import (
-	"io"
+	"context"
 	"os"
 	"os/exec"
 	"strconv"
@@ -26,6 +26,7 @@ import (
 	"github.com/pkg/errors"
 
 	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
+	"go.ligato.io/cn-infra/v2/logging"
 )
 
 // Marked defines that the process should be always restarted
@@ -34,30 +35,36 @@ const infiniteRestarts = -1
 // DefaultPDeathSignal is default signal used for parent death process attribute
 var DefaultPDeathSignal = syscall.SIGKILL
 
-func (p *Process) startProcess() (cmd *exec.Cmd, err error) {
-	cmd, err = defaultProcessAttrs(p.cmd)
+func (p *Process) startProcess() error {
+	command := p.GetCommand()
+	cmd, err := defaultProcessAttrs(command)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if p.options != nil && p.options.shell != "" {
+		if err = p.useShell(cmd, command); err != nil {
+			return err
+		}
 	}
 
 	// if options are set, adjust command attributes, otherwise set last required fields to prepare the command
 	if p.options != nil {
 		// args
-		cmd.Args = append(cmd.Args, p.options.args...)
+		cmd.Args = append(cmd.Args, p.GetArguments()...)
 		// writer
-		if p.options.outWriter != nil {
+		if p.options.outWriter != nil || p.options.outputHandler != nil {
 			stdout, err := cmd.StdoutPipe()
 			if err != nil {
 				p.log.Errorf("failed to get stdout pipe: %v", err)
 			}
-			p.watchOutput(p.options.outWriter, stdout)
+			p.watchOutput(Stdout, p.options.outWriter, stdout)
 		}
-		if p.options.errWriter != nil {
+		if p.options.errWriter != nil || p.options.outputHandler != nil {
 			errOut, err := cmd.StderrPipe()
 			if err != nil {
 				p.log.Errorf("failed to get stderr pipe: %v", err)
 			}
-			p.watchOutput(p.options.errWriter, errOut)
+			p.watchOutput(Stderr, p.options.errWriter, errOut)
 		}
 		// detach (replace default)
 		if p.options.detach {
@@ -70,16 +77,37 @@ func (p *Process) startProcess() (cmd *exec.Cmd, err error) {
 		if p.options.environ != nil {
 			cmd.Env = p.options.environ
 		}
+		// environment file, read again on every start so that changes apply after restart
+		if p.options.envFile != "" {
+			fileEnv, err := loadEnvFile(p.options.envFile)
+			if err != nil {
+				return err
+			}
+			base := cmd.Env
+			if base == nil {
+				base = os.Environ()
+			}
+			cmd.Env = mergeEnv(base, fileEnv)
+		}
+		// extra files (fd 3, 4, ...), owned by the caller and not closed after start
+		cmd.ExtraFiles = p.options.extraFiles
 	}
 
+	p.setReady(false)
+	p.setStopReason(StopReasonNone)
 	err = cmd.Start()
 	if err != nil {
-		return nil, errors.Errorf("failed to start new process (cmd: %s): %v", p.cmd, err)
+		return errors.Errorf("failed to start new process (cmd: %s): %v", command, err)
 	}
-	p.startTime = time.Now()
+	startTime := p.setCommand(cmd)
+	if p.wasStarted() {
+		p.recordRestart(startTime)
+	}
+	p.setStarted()
+	p.audit(cmd)
 
 	// now the process is running, start the status watcher
-	if !p.isWatched {
+	if !p.isWatching() {
 		go p.watch()
 	}
 
@@ -96,7 +124,7 @@ func (p *Process) startProcess() (cmd *exec.Cmd, err error) {
 		_, err = p.sh.ReadStatusFromPID(cmd.Process.Pid)
 	}
 
-	return cmd, err
+	return err
 }
 
 func defaultProcessAttrs(cmd string) (*exec.Cmd, error) {
@@ -115,40 +143,85 @@ func defaultProcessAttrs(cmd string) (*exec.Cmd, error) {
 	}, nil
 }
 
+// useShell changes the command to run the command string with the shell. The process name is passed
+// as $0 and process arguments as positional parameters ($1, $2, ...).
+func (p *Process) useShell(cmd *exec.Cmd, command string) error {
+	shell, err := exec.LookPath(p.options.shell)
+	if err != nil {
+		return errors.Errorf("shell %s for process %s not found: %v", p.options.shell, p.name, err)
+	}
+	cmd.Path = shell
+	cmd.Args = []string{p.options.shell, "-c", command, p.name}
+	return nil
+}
+
 func (p *Process) stopProcess() (err error) {
-	if p.command == nil || p.command.Process == nil {
+	proc := p.osProcess()
+	if proc == nil {
 		return errors.Errorf("asked to stop non-existing process instance")
 	}
 
-	if err = p.command.Process.Signal(syscall.SIGTERM); err != nil && !strings.Contains(err.Error(), alreadyFinished) {
+	if p.isAlive() {
+		p.setStopReason(StopReasonStop)
+		p.runPreStop()
+	}
+
+	if err = proc.Signal(syscall.SIGTERM); err != nil && !strings.Contains(err.Error(), alreadyFinished) {
 		return errors.Errorf("process termination unsuccessful: %v", err)
 	}
 
-	p.startTime = time.Time{}
+	p.clearStartTime()
 	return nil
 }
 
+// runs pre-stop command (if defined) and waits for it to finish up to the timeout. Errors are only logged,
+// so the process is always stopped afterwards
+func (p *Process) runPreStop() {
+	if p.options == nil || len(p.options.preStopCmd) == 0 {
+		return
+	}
+	ctx := context.Background()
+	if p.options.preStopTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.options.preStopTimeout)
+		defer cancel()
+	}
+	preStop := p.options.preStopCmd
+	cmd := exec.CommandContext(ctx, preStop[0], preStop[1:]...)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = ctx.Err()
+		}
+		p.log.Warnf("Pre-stop command for process %s failed, stopping anyway: %v", p.name, err)
+		return
+	}
+	p.log.Debugf("Pre-stop command for process %s finished", p.name)
+}
+
 func (p *Process) forceStopProcess() (err error) {
-	if p.command == nil || p.command.Process == nil {
+	proc := p.osProcess()
+	if proc == nil {
 		return errors.Errorf("asked to force-stop non-existing process instance")
 	}
+	p.setStopReason(StopReasonKill)
 
-	if err = p.command.Process.Signal(syscall.SIGKILL); err != nil && !strings.Contains(err.Error(), alreadyFinished) {
+	if err = proc.Signal(syscall.SIGKILL); err != nil && !strings.Contains(err.Error(), alreadyFinished) {
 		return errors.Errorf("process forced termination unsuccessful: %v", err)
 	}
-	if err = p.command.Process.Release(); err != nil {
+	if err = proc.Release(); err != nil {
 		return errors.Errorf("resource release failed: %v", err)
 	}
 
-	p.startTime = time.Time{}
+	p.clearStartTime()
 	return nil
 }
 
 func (p *Process) isAlive() bool {
-	if p.command == nil || p.command.Process == nil {
+	proc := p.osProcess()
+	if proc == nil {
 		return false
 	}
-	osProcess, err := os.FindProcess(p.command.Process.Pid)
+	osProcess, err := os.FindProcess(proc.Pid)
 	if err != nil {
 		return false
 	}
@@ -162,16 +235,22 @@ func (p *Process) isAlive() bool {
 
 // waits until the command completes
 func (p *Process) waitOnProcess() (*os.ProcessState, error) {
-	if p.command == nil || p.command.Process == nil {
+	proc := p.osProcess()
+	if proc == nil {
 		return &os.ProcessState{}, nil
 	}
 
-	return p.command.Process.Wait()
+	state, err := proc.Wait()
+	if err == nil {
+		p.clearPid(proc.Pid)
+		p.setLastExit(state)
+	}
+	return state, err
 }
 
 // stops the process and internal watcher
 func (p *Process) deleteProcess() error {
-	if p.command == nil || p.command.Process == nil {
+	if p.osProcess() == nil {
 		return nil
 	}
 
@@ -186,13 +265,14 @@ func (p *Process) deleteProcess() error {
 
 // sends custom signal to process
 func (p *Process) signalToProcess(signal os.Signal) error {
-	if p.command == nil || p.command.Process == nil {
+	proc := p.osProcess()
+	if proc == nil {
 		err := errors.Errorf("attempt to send signal to non-running process")
 		p.log.Error(err)
 		return err
 	}
 
-	return p.command.Process.Signal(signal)
+	return proc.Signal(signal)
 }
 
 // setProcessCpuAffinity process CPU affinity. Unsuccessful CPU assignment does not return error
@@ -228,25 +308,72 @@ func (p *Process) setProcessCPUAffinity(cmd *exec.Cmd) {
 // status is updated. If process status was changed, notification is sent. In addition, terminated processes are
 // restarted if allowed by policy, and dead processes are cleaned up.
 func (p *Process) watch() {
-	if p.isWatched {
+	if !p.setWatched(true) {
 		p.log.Warnf("Process watcher already running")
 		return
 	}
 
 	p.log.Debugf("Process %s watcher started", p.name)
-	p.isWatched = true
-	ticker := time.NewTicker(1 * time.Second)
+	p.startNotifier()
+	poll := newPollInterval(p.options)
+	ticker := time.NewTicker(poll.current)
 
 	var last status.ProcessStatus
-	var numRestarts int32
+	var lastPid int
+	fds := newFDMonitor(p.options)
+	policy := newRestartPolicy(p.options)
 	var autoTerm bool
 	if p.options != nil {
-		numRestarts = p.options.restart
+		p.initRestarts(p.options.restart)
 		autoTerm = p.options.autoTerm
 	}
 
+	// periodic restart timer
+	var schedule *cronSchedule
+	var scheduleTimer *time.Timer
+	var scheduleChan <-chan time.Time
+	resetSchedule := func() {
+		if next := schedule.next(time.Now()); !next.IsZero() {
+			scheduleTimer = time.NewTimer(time.Until(next))
+			scheduleChan = scheduleTimer.C
+			p.log.Debugf("Next periodic restart of process %s scheduled at %v", p.name, next)
+		}
+	}
+	if p.options != nil && p.options.periodicRestart != "" {
+		var err error
+		if schedule, err = parseCron(p.options.periodicRestart); err != nil {
+			p.log.Errorf("periodic restart of process %s disabled: %v", p.name, err)
+		} else {
+			resetSchedule()
+		}
+	}
+
+	// lifecycle deadline timer, the terminal event is reported once the process is stopped
+	var deadlineChan <-chan time.Time
+	var deadlinePending bool
+	deadlineTimer := newDeadlineTimer(p.options)
+	if deadlineTimer != nil {
+		deadlineChan = deadlineTimer.C
+	}
+
 	for {
 		select {
+		case <-deadlineChan:
+			deadlineChan = nil
+			if deadlinePending = p.exceedDeadline(); !deadlinePending {
+				p.reportDeadline(last)
+			}
+		case <-scheduleChan:
+			switch {
+			case p.isRestarting():
+				p.log.Infof("Skipping periodic restart of process %s, it is already being restarted", p.name)
+			case !p.isAlive():
+				p.log.Debugf("Skipping periodic restart of process %s, it is not running", p.name)
+			default:
+				p.log.Infof("Periodic restart of process %s", p.name)
+				p.restartAsync("periodic")
+			}
+			resetSchedule()
 		case <-ticker.C:
 			var current status.ProcessStatus
 			// skip initial status since the process is not running yet
@@ -255,6 +382,9 @@ func (p *Process) watch() {
 			}
 			if !p.isAlive() {
 				current = status.Terminated
+				if proc := p.osProcess(); proc != nil {
+					p.clearPid(proc.Pid)
+				}
 			} else {
 				pStatus, err := p.GetStatus(p.GetPid())
 				if err != nil {
@@ -262,25 +392,94 @@ func (p *Process) watch() {
 				}
 				if pStatus.State == "" {
 					current = status.Unavailable
+				} else if !p.IsReady() && pStatus.State != status.Zombie {
+					current = status.Starting
 				} else {
 					current = pStatus.State
 				}
+				if fds.check(p, p.GetPid()) && !p.isRestarting() {
+					p.restartAsync("FD limit")
+				}
 			}
 			// identify status change
 			if current != last {
-				if p.GetNotificationChan() != nil {
-					p.options.notifyChan <- current
+				p.notify(current)
+				var exit *ExitClassification
+				var diagnostics string
+				if current == status.Terminated {
+					if exit = p.LastExit(); exit != nil && p.osProcess() != nil && exit.Pid == p.osProcess().Pid {
+						p.log.WithFields(logging.Fields{
+							"exit":   exit.Category,
+							"code":   exit.ExitCode,
+							"signal": exit.Signal,
+						}).Infof("process %s terminated: %s", p.name, exit.Description)
+						diagnostics = p.runOnCrash(exit)
+					} else {
+						exit = nil
+					}
+				}
+				p.publish(last, current, diagnostics)
+				if current == status.Terminated && deadlinePending {
+					deadlinePending = false
+					p.reportDeadline(current)
 				}
 				// handle automatic process restarts
 				if current == status.Terminated {
-					if numRestarts > 0 || numRestarts == infiniteRestarts {
+					var uptime time.Duration
+					if startTime := p.GetStartTime(); !startTime.IsZero() {
+						uptime = time.Since(startTime)
+					}
+					if p.isRestarting() {
+						p.log.Debugf("process %s terminated while being restarted, automatic restart skipped", p.name)
+					} else if p.isShutdown() {
+						p.log.Debugf("process %s terminated during shutdown, automatic restart skipped", p.name)
+					} else if p.isDeadlineExceeded() {
+						p.log.Debugf("process %s terminated after its lifecycle deadline, automatic restart skipped", p.name)
+					} else if p.stoppedByOperator() {
+						p.log.Debugf("process %s was stopped by the plugin, automatic restart skipped", p.name)
+					} else if policyDelay, restart := policy.delay(exit, uptime); !restart {
+						category := ExitUnknown
+						if exit != nil {
+							category = exit.Category
+						}
+						p.log.Debugf("process %s exited (%s), restart policy skipped automatic restart", p.name, category)
+					} else if p.takeRestart() {
+						delay := policyDelay + p.restartDelay(time.Now().Add(policyDelay))
+						if delay > policyDelay {
+							p.log.Infof("restart of process %s deferred by %v until the next restart window", p.name, delay)
+							p.notify(status.RestartDeferred)
+							p.publish(current, status.RestartDeferred, "")
+						} else if delay > 0 {
+							p.log.Debugf("restart of process %s delayed by %v", p.name, delay)
+						}
 						go func() {
+							if delay > 0 {
+								timer := time.NewTimer(delay)
+								select {
+								case <-timer.C:
+								case <-p.cancelChan:
+									timer.Stop()
+									return
+								}
+								if p.isAlive() {
+									// started meanwhile by other means
+									return
+								}
+							}
+							if p.isDeadlineExceeded() {
+								return
+							}
+							p.setRestarting(true)
+							defer p.setRestarting(false)
 							var err error
-							if p.command, err = p.startProcess(); err != nil {
+							if err = p.startProcess(); err != nil {
 								p.log.Error("attempt to restart process %s failed: %v", p.name, err)
+								return
+							}
+							if err = p.waitReady(); err != nil {
+								p.log.Warnf("restarted process %s is not ready: %v", p.name, err)
 							}
 						}()
-						numRestarts--
 					} else {
 						p.log.Debugf("no more attempts to restart process %s", p.name)
 					}
@@ -293,35 +492,36 @@ func (p *Process) watch() {
 					}
 				}
 			}
-			last = current
+			// adjust the poll interval, a new process instance is polled fast again
+			pid, _ := p.Pid()
+			if poll.update(isStable(current) && current == last && pid == lastPid) {
+				ticker.Stop()
+				ticker = time.NewTicker(poll.current)
+			}
+			last, lastPid = current, pid
 		case <-p.cancelChan:
 			ticker.Stop()
-			p.closeNotifyChan()
+			if scheduleTimer != nil {
+				scheduleTimer.Stop()
+			}
+			if deadlineTimer != nil {
+				deadlineTimer.Stop()
+			}
+			p.setWatched(false)
+			p.closeSubscriptions()
+			p.log.Debugf("Process %s watcher stopped", p.name)
 			return
 		}
 	}
 }
 
-// Watch output (either standard or custom). Terminates with process, since io.Copy reaches EOF.
-func (p *Process) watchOutput(w io.Writer, r io.Reader) {
+// restartAsync restarts the process in the background, the reason is used in the error log
+func (p *Process) restartAsync(reason string) {
+	p.setRestarting(true)
 	go func() {
-		if _, err := io.Copy(w, r); err != nil {
-			p.log.Errorf("Output watcher error: %v", err)
+		defer p.setRestarting(false)
+		if err := p.Restart(); err != nil {
+			p.log.Errorf("%s restart of process %s failed: %v", reason, p.name, err)
 		}
 	}()
 }
-
-func (p *Process) closeNotifyChan() {
-	// rescue wheel if somebody forgets to read the doc
-	defer func() {
-		if r := recover(); r != nil {
-			p.log.Warnf("notify channel should not be closed by provider (recovered from panic: %v)", r)
-		}
-	}()
-	if p.GetNotificationChan() != nil {
-		close(p.options.notifyChan)
-	}
-
-	p.isWatched = false
-	p.log.Debugf("Process %s watcher stopped", p.name)
-}
@@ -16,6 +16,7 @@ package processmanager
 
 import (
 	"io"
+	"os"
 	"time"
 
 	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
@@ -44,7 +45,8 @@ type POptions struct {
 	runOnStartup bool
 
 	// notify
-	notifyChan chan status.ProcessStatus
+	notifyChan   chan status.ProcessStatus
+	notifyBuffer int
 
 	// auto-terminate
 	autoTerm bool
@@ -53,6 +55,63 @@ type POptions struct {
 	cpuAffinityMask  string
 	cpuAffinityList  string
 	cpuAffinityDelay time.Duration
+
+	// readiness
+	readinessProbe *ReadinessProbe
+
+	// audit
+	auditHook      AuditHook
+	auditEnvValues bool
+
+	// pre-stop
+	preStopCmd     []string
+	preStopTimeout time.Duration
+
+	// restart schedule
+	restartWindows []TimeWindow
+
+	// periodic restart
+	periodicRestart string
+
+	// labels
+	labels map[string]string
+
+	// start stopped
+	startStopped bool
+
+	// extra files
+	extraFiles []*os.File
+
+	// adaptive poll
+	pollMin time.Duration
+	pollMax time.Duration
+
+	// fd limit
+	fdLimit        int
+	fdLimitRestart bool
+
+	// restart delays
+	restartDelays *RestartDelays
+
+	// shell
+	shell string
+
+	// stop timeout
+	stopTimeout time.Duration
+
+	// output lines
+	outputHandler    func(OutputLine)
+	outputTimestamps bool
+
+	// on-crash command
+	onCrashCmd     []string
+	onCrashTimeout time.Duration
+
+	// lifecycle deadline
+	lifecycleDeadline time.Duration
+
+	// environment file
+	envFile string
 }
 
 // POption is helper function to set process options
@@ -65,7 +124,8 @@ func Args(args ...string) POption {
 	}
 }
 
-// Restarts defines number of automatic restarts of given process
+// Restarts defines number of automatic restarts of given process. Processes stopped by Stop or Kill are not
+// restarted automatically.
 func Restarts(restart int32) POption {
 	return func(p *POptions) {
 		p.restart = restart
@@ -103,7 +163,10 @@ func Template(runOnStartup bool) POption {
 	}
 }
 
-// Notify will send process status change notifications to the provided channel
+// Notify will send process status change notifications to the provided channel. Notifications are buffered
+// (see WithNotifyBuffer) and delivered in order. If the buffer is full because the consumer does not keep up,
+// new notifications are dropped (and counted, see DroppedNotifications), so a slow consumer never stalls
+// the process watcher.
 // Note: caller should not close the channel, since plugin is a sender, it handles the close
 func Notify(notifyChan chan status.ProcessStatus) POption {
 	return func(p *POptions) {
@@ -126,3 +189,200 @@ func CPUAffinity(mask, list string, delay time.Duration) POption {
 		p.cpuAffinityDelay = delay
 	}
 }
+
+// WithReadinessProbe defines a probe which must pass before the started process is considered ready.
+// Start and Restart block until the probe passes or its timeout elapses.
+func WithReadinessProbe(probe ReadinessProbe) POption {
+	return func(p *POptions) {
+		p.readinessProbe = &probe
+	}
+}
+
+// WithAuditHook sets a hook called with the audit event every time the process is started
+func WithAuditHook(hook AuditHook) POption {
+	return func(p *POptions) {
+		p.auditHook = hook
+	}
+}
+
+// WithAuditEnvValues includes environment variable values in audit events. By default, only variable names
+// are audited to avoid leaking secrets into audit logs
+func WithAuditEnvValues() POption {
+	return func(p *POptions) {
+		p.auditEnvValues = true
+	}
+}
+
+// WithPreStopCommand defines a command (e.g. a drain script deregistering the service from a load balancer)
+// which is run before the process receives the termination signal. The stop waits for the command up to the
+// given timeout. If the command fails or times out, the error is logged and the process is stopped anyway.
+func WithPreStopCommand(cmd []string, timeout time.Duration) POption {
+	return func(p *POptions) {
+		p.preStopCmd = cmd
+		p.preStopTimeout = timeout
+	}
+}
+
+// WithRestartSchedule restricts automatic restarts to given daily time windows. If the process terminates outside
+// of all windows, the restart is deferred until the next window starts (status.RestartDeferred is sent
+// to the notification channel). Without windows, the process is restarted immediately.
+func WithRestartSchedule(windows []TimeWindow) POption {
+	return func(p *POptions) {
+		p.restartWindows = windows
+	}
+}
+
+// WithPeriodicRestart restarts the running process at times given by the cron expression (e.g. "0 3 * * *"
+// for a nightly restart), regardless of its state. The restart is the same as Restart, including pre-stop command
+// and readiness probe. The scheduled restart is skipped if the process is not running or is being restarted.
+func WithPeriodicRestart(schedule string) POption {
+	return func(p *POptions) {
+		p.periodicRestart = schedule
+	}
+}
+
+// WithNotifyBuffer sets the number of notifications buffered for the notification channel consumer
+// (default is 16)
+func WithNotifyBuffer(size int) POption {
+	return func(p *POptions) {
+		p.notifyBuffer = size
+	}
+}
+
+// WithLabels assigns labels (e.g. role=database) to the process, which can be used to select processes
+// with ListByLabel
+func WithLabels(labels map[string]string) POption {
+	return func(p *POptions) {
+		p.labels = labels
+	}
+}
+
+// WithStartStopped marks the process to be created without being started. Nothing is launched and the process
+// watcher does not run until Start is explicitly called, the status is reported as status.NotStarted until then.
+// Reconcile creates such processes without starting them.
+func WithStartStopped() POption {
+	return func(p *POptions) {
+		p.startStopped = true
+	}
+}
+
+// WithExtraFiles passes additional open files (e.g. a shared log file or a pre-bound listener socket) to the process.
+// Following the os/exec convention, the child receives them as file descriptors 3, 4, ... in the order given.
+// The files are passed again on every restart, so they are never closed by the plugin. The caller owns them and
+// must keep them open for the whole lifetime of the process.
+func WithExtraFiles(files []*os.File) POption {
+	return func(p *POptions) {
+		p.extraFiles = files
+	}
+}
+
+// WithAdaptivePoll makes the process watcher poll the status every min interval after the process is (re)started,
+// to detect early crashes quickly. While the process is stable, the interval is doubled with every poll up to max.
+// Any detected problem (or a new process instance) resets the interval to min. Without this option, the status
+// is polled every second.
+func WithAdaptivePoll(min, max time.Duration) POption {
+	return func(p *POptions) {
+		p.pollMin = min
+		p.pollMax = max
+	}
+}
+
+// WithFDLimit enables monitoring of the number of file descriptors opened by the process (sampled every poll
+// of the process watcher). When the count reaches 90% of the limit, a warning is logged and status.FDLimitWarning
+// is sent to the notification channel. Supported only on Linux, elsewhere the monitoring is disabled with
+// a warning.
+func WithFDLimit(limit int) POption {
+	return func(p *POptions) {
+		p.fdLimit = limit
+	}
+}
+
+// WithFDLimitRestart restarts the process once the number of its open file descriptors reaches the limit set
+// by WithFDLimit, e.g. to recover a process leaking descriptors before it fails with "too many open files".
+func WithFDLimitRestart() POption {
+	return func(p *POptions) {
+		p.fdLimitRestart = true
+	}
+}
+
+// WithRestartDelays sets delays of automatic restarts (see Restarts) depending on the way the process terminated,
+// e.g. to restart crashed process immediately (with backoff), but wait longer after a clean exit. Processes stopped
+// by Stop or Kill are never restarted automatically.
+func WithRestartDelays(delays RestartDelays) POption {
+	return func(p *POptions) {
+		p.restartDelays = &delays
+	}
+}
+
+// WithShell runs the process command as a shell command string (e.g. "exec server --port 80 2>&1 | tee log")
+// using `<shell> -c` instead of executing it directly (which is the default). If the shell is empty, /bin/sh
+// is used. Arguments (see Args) are passed to the shell command as positional parameters $1, $2, ...
+//
+// Warning: the command string is interpreted by the shell, so it must never be built from untrusted input,
+// since that allows shell injection (e.g. "; rm -rf /"). Pass untrusted values as arguments and refer to them
+// as quoted positional parameters ("$1") instead.
+func WithShell(shell string) POption {
+	return func(p *POptions) {
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		p.shell = shell
+	}
+}
+
+// WithStopTimeout sets the time the process has to terminate after the termination signal during the plugin
+// shutdown (see RunUntilSignal), before it is killed. Default is 10 seconds.
+func WithStopTimeout(timeout time.Duration) POption {
+	return func(p *POptions) {
+		p.stopTimeout = timeout
+	}
+}
+
+// WithOutputHandler subscribes the handler to the process output. The handler is called for every line read
+// from the standard and error output (also if no writer is set), so it should not block.
+func WithOutputHandler(handler func(line OutputLine)) POption {
+	return func(p *POptions) {
+		p.outputHandler = handler
+	}
+}
+
+// WithOutputTimestamps stamps every output line with the time it was fully read from the pipe (see OutputLine).
+// Lines written to the output writers are prefixed with the timestamp in RFC3339 format.
+func WithOutputTimestamps() POption {
+	return func(p *POptions) {
+		p.outputTimestamps = true
+	}
+}
+
+// WithOnCrash defines a diagnostic command (e.g. collecting a core dump or dmesg) which is run when the process
+// ends on its own with other than clean exit, before it is restarted. The crashed process is described by
+// environment variables (PM_PROCESS, PM_PID, PM_EXIT_CATEGORY, PM_EXIT_CODE and PM_EXIT_SIGNAL). The restart
+// waits for the command up to the given timeout (10 seconds if the timeout is not positive). Output of the
+// command is attached to the event of transition to the terminated state (see ProcessEvent.Diagnostics).
+func WithOnCrash(cmd []string, timeout time.Duration) POption {
+	return func(p *POptions) {
+		p.onCrashCmd = cmd
+		p.onCrashTimeout = timeout
+	}
+}
+
+// WithLifecycleDeadline limits the whole lifecycle of the process (including automatic restarts) to the given
+// duration, counted from its first start. Once the deadline elapses, the process is stopped the same way as during
+// the plugin shutdown (see WithStopTimeout) regardless of its state, and it is not restarted automatically anymore.
+// The status.DeadlineExceeded event is sent when the process is stopped. Useful for time-boxed batch jobs.
+func WithLifecycleDeadline(deadline time.Duration) POption {
+	return func(p *POptions) {
+		p.lifecycleDeadline = deadline
+	}
+}
+
+// WithEnvFile loads environment variables from the file (KEY=VALUE lines, see below) and merges them over
+// the process environment (set by EnvVar, or inherited from the parent). The file is read again every time
+// the process is started, so changes are applied by Restart without changing the process definition.
+// Empty lines and lines starting with '#' are ignored, values may be quoted with double quotes (with escape
+// sequences) or single quotes (literal). The process fails to start if the file cannot be read or parsed.
+func WithEnvFile(path string) POption {
+	return func(p *POptions) {
+		p.envFile = path
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"context"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.ligato.io/cn-infra/v2/exec/processmanager/status"
+)
+
+// Default readiness probe timing
+const (
+	defaultProbeInterval = 100 * time.Millisecond
+	defaultProbeTimeout  = 30 * time.Second
+)
+
+// ReadinessProbe defines how to find out that the started process is ready to serve. Either the socket
+// (Network and Address) or the File (or both) should be set. The probe is polled with given interval
+// until it passes or the timeout elapses.
+type ReadinessProbe struct {
+	// Network is the socket type ("tcp", "unix", ...) of the Address. Defaults to "tcp"
+	Network string
+	// Address of the socket which must accept connections, e.g. "127.0.0.1:9191" or a unix socket path
+	Address string
+	// File which must exist
+	File string
+	// Interval between probe attempts, defaults to 100ms
+	Interval time.Duration
+	// Timeout after the process start within which it must become ready, defaults to 30s
+	Timeout time.Duration
+}
+
+// Check runs the probe once. Returns nil if the process is ready.
+func (r *ReadinessProbe) Check() error {
+	if r.Address != "" {
+		network := r.Network
+		if network == "" {
+			network = "tcp"
+		}
+		conn, err := net.DialTimeout(network, r.Address, r.interval())
+		if err != nil {
+			return errors.Errorf("socket %s is not available: %v", r.Address, err)
+		}
+		if err := conn.Close(); err != nil {
+			return errors.Errorf("failed to close probe connection: %v", err)
+		}
+	}
+	if r.File != "" {
+		if _, err := os.Stat(r.File); err != nil {
+			return errors.Errorf("file %s is not available: %v", r.File, err)
+		}
+	}
+	return nil
+}
+
+func (r *ReadinessProbe) interval() time.Duration {
+	if r.Interval <= 0 {
+		return defaultProbeInterval
+	}
+	return r.Interval
+}
+
+func (r *ReadinessProbe) timeout() time.Duration {
+	if r.Timeout <= 0 {
+		return defaultProbeTimeout
+	}
+	return r.Timeout
+}
+
+// IsReady returns true if the process is running and passed its readiness probe. Processes without
+// the probe are ready as soon as they are started.
+func (p *Process) IsReady() bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.ready
+}
+
+func (p *Process) setReady(ready bool) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.ready = ready
+}
+
+func (p *Process) setStarted() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.started = true
+	if p.status != nil && p.status.State == status.NotStarted {
+		p.status.State = status.Initial
+	}
+}
+
+func (p *Process) wasStarted() bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.started
+}
+
+// waitReady polls the readiness probe (if defined) until the process is ready, the process dies
+// or the probe timeout elapses
+func (p *Process) waitReady() error {
+	if p.options == nil || p.options.readinessProbe == nil {
+		p.setReady(true)
+		return nil
+	}
+	probe := p.options.readinessProbe
+	deadline := time.Now().Add(probe.timeout())
+	for {
+		err := probe.Check()
+		if err == nil {
+			p.setReady(true)
+			p.log.Debugf("Process %s is ready", p.name)
+			return nil
+		}
+		if !p.isAlive() {
+			return errors.Errorf("process %s terminated before it became ready", p.name)
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("process %s did not become ready within %v: %v", p.name, probe.timeout(), err)
+		}
+		time.Sleep(probe.interval())
+	}
+}
+
+// WaitHealthy blocks until the process is alive and passes its readiness probe (if defined). If the process
+// was not started yet, it waits for the start. Returns error if the process terminates (unless it is being
+// restarted) or the context is done first.
+func (p *Process) WaitHealthy(ctx context.Context) error {
+	interval := defaultProbeInterval
+	if p.options != nil && p.options.readinessProbe != nil {
+		interval = p.options.readinessProbe.interval()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if p.isHealthy() {
+			return nil
+		}
+		if p.wasStarted() && !p.isRestarting() && !p.isAlive() {
+			return errors.Errorf("process %s terminated before it became healthy", p.name)
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("waiting for process %s to become healthy: %v", p.name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Process) isHealthy() bool {
+	if !p.wasStarted() || !p.isAlive() {
+		return false
+	}
+	if p.options == nil || p.options.readinessProbe == nil {
+		return true
+	}
+	return p.options.readinessProbe.Check() == nil
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ProcessSpec describes desired state of a single managed process
+type ProcessSpec struct {
+	// Name identifies the process within the plugin
+	Name string
+	// Cmd is the command used to start the process
+	Cmd string
+	// Options are the same options accepted by NewProcess
+	Options []POption
+}
+
+// ReconcileResult reports which processes were affected by Reconcile
+type ReconcileResult struct {
+	// Started contains names of newly created and started processes, as well as of stopped processes with
+	// unchanged spec which were started again
+	Started []string
+	// Created contains names of newly created processes which were not started (see WithStartStopped), as well
+	// as of never started processes replaced due to a changed spec
+	Created []string
+	// Stopped contains names of processes which were stopped and removed
+	Stopped []string
+	// Restarted contains names of processes replaced by a new instance due to a changed spec
+	Restarted []string
+	// Unchanged contains names of processes left alone
+	Unchanged []string
+	// Failed contains errors for processes which could not be reconciled
+	Failed map[string]error
+}
+
+// Reconcile compares desired process specs with processes known to the plugin (matched by name). Processes
+// missing in specs are stopped and removed, new ones are created and started and those with a changed command
+// or options are stopped and replaced by a new instance built from the spec (started only if the original
+// process was started before). The replacement keeps the
+// notification channel and event subscriptions of the original process, but instances obtained earlier
+// (e.g. with GetProcessByName) no longer represent the managed process. Processes with unchanged spec are
+// left alone, unless they were stopped, in which case they are started again.
+func (p *Plugin) Reconcile(specs []ProcessSpec) ReconcileResult {
+	result := ReconcileResult{
+		Failed: make(map[string]error),
+	}
+
+	desired := make(map[string]ProcessSpec, len(specs))
+	for _, spec := range specs {
+		desired[spec.Name] = spec
+	}
+
+	// stop processes which are no longer desired
+	for _, pr := range p.listProcesses() {
+		if _, ok := desired[pr.name]; ok {
+			continue
+		}
+		if pr.isAlive() {
+			if _, err := pr.StopAndWait(); err != nil {
+				result.Failed[pr.name] = errors.Errorf("failed to stop process: %v", err)
+				continue
+			}
+		}
+		if err := p.Delete(pr.name); err != nil {
+			result.Failed[pr.name] = errors.Errorf("failed to delete process: %v", err)
+			continue
+		}
+		result.Stopped = append(result.Stopped, pr.name)
+	}
+
+	for _, spec := range specs {
+		pr := p.getProcess(spec.Name)
+		if pr == nil {
+			newPr := p.NewProcess(spec.Name, spec.Cmd, spec.Options...)
+			if newPr.(*Process).options.startStopped {
+				result.Created = append(result.Created, spec.Name)
+				continue
+			}
+			if err := newPr.Start(); err != nil {
+				result.Failed[spec.Name] = errors.Errorf("failed to start process: %v", err)
+				continue
+			}
+			result.Started = append(result.Started, spec.Name)
+			continue
+		}
+
+		newPr := p.newProcess(spec.Name, spec.Cmd, spec.Options...)
+		if pr.cmd == spec.Cmd && equalOptions(pr.options, newPr.options) {
+			if pr.wasStarted() && !pr.isAlive() && !pr.isRestarting() {
+				if err := pr.Start(); err != nil {
+					result.Failed[spec.Name] = errors.Errorf("failed to start process: %v", err)
+					continue
+				}
+				result.Started = append(result.Started, spec.Name)
+				continue
+			}
+			result.Unchanged = append(result.Unchanged, spec.Name)
+			continue
+		}
+		// a process which was never started is left for an explicit Start
+		started := pr.wasStarted()
+		if err := p.replace(pr, newPr); err != nil {
+			result.Failed[spec.Name] = err
+			continue
+		}
+		if !started {
+			result.Created = append(result.Created, spec.Name)
+			continue
+		}
+		if err := newPr.Start(); err != nil {
+			result.Failed[spec.Name] = errors.Errorf("failed to start process: %v", err)
+			continue
+		}
+		result.Restarted = append(result.Restarted, spec.Name)
+	}
+
+	return result
+}
+
+// replace stops the old process and puts the new one in its place
+func (p *Plugin) replace(oldPr, newPr *Process) error {
+	// keep the notification channel since it may be watched by the caller
+	if newPr.options.notifyChan == nil && oldPr.options != nil {
+		newPr.options.notifyChan = oldPr.options.notifyChan
+	}
+	if oldPr.isAlive() {
+		if _, err := oldPr.StopAndWait(); err != nil {
+			return errors.Errorf("failed to stop process: %v", err)
+		}
+	}
+	// subscriptions are moved before the watcher of the old process is closed, since it closes them on exit
+	oldPr.moveSubscriptions(newPr)
+	if err := p.replaceProcess(oldPr, newPr); err != nil {
+		newPr.moveSubscriptions(oldPr)
+		return errors.Errorf("failed to replace process: %v", err)
+	}
+	if newPr.options.template {
+		p.writeAsTemplate(newPr)
+	}
+	return nil
+}
+
+// returns process with given name, or nil if it does not exist
+func (p *Plugin) getProcess(name string) *Process {
+	for _, pr := range p.listProcesses() {
+		if pr.name == name {
+			return pr
+		}
+	}
+	return nil
+}
+
+// equalOptions compares process options. Writers, hooks and notification channels are not compared since
+// they cannot be meaningfully described by a spec, all other fields are compared.
+func equalOptions(a, b *POptions) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ca, cb := *a, *b
+	for _, o := range []*POptions{&ca, &cb} {
+		o.outWriter, o.errWriter = nil, nil
+		o.notifyChan = nil
+		o.auditHook = nil
+		o.outputHandler = nil
+	}
+	return reflect.DeepEqual(ca, cb)
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// Replace changes the command and arguments of the process and restarts it gracefully (see Restart) to run
+// the new binary, e.g. for a rolling upgrade. The process instance is preserved, with its labels, restart
+// history, notification channel and event subscriptions. The new binary is validated (it must exist and be
+// executable) before the old one is stopped. For processes run with WithShell, the command is a shell
+// command string and is not validated.
+func (p *Process) Replace(cmd string, args []string) error {
+	if p.options == nil {
+		return errors.Errorf("cannot replace command of process %s: process has no options to hold arguments", p.name)
+	}
+	if p.options.shell == "" {
+		if _, err := exec.LookPath(cmd); err != nil {
+			return errors.Errorf("cannot replace command of process %s: %v", p.name, err)
+		}
+	}
+
+	p.mx.Lock()
+	p.cmd = cmd
+	p.options.args = args
+	p.mx.Unlock()
+
+	p.log.Infof("Replacing command of process %s with %s %v", p.name, cmd, args)
+	return p.Restart()
+}
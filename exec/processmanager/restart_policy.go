@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"time"
+)
+
+// RestartDelays defines delays of automatic restarts (see Restarts) by the way the process terminated
+// (see ClassifyExit). Processes stopped by the plugin (Stop, Kill) are never restarted automatically.
+type RestartDelays struct {
+	// Crash is the delay after the process exited with non-zero code, crashed or was killed by a signal.
+	// Zero restarts the process immediately.
+	Crash time.Duration
+	// MaxCrash enables exponential backoff if greater than (non-zero) Crash: the delay doubles with every
+	// consecutive crash up to MaxCrash. The backoff is reset once the process runs for MaxCrash before crashing.
+	MaxCrash time.Duration
+	// CleanExit is the delay after the process exited with zero code
+	CleanExit time.Duration
+}
+
+// restartPolicy decides about automatic restarts of the process according to RestartDelays
+type restartPolicy struct {
+	delays  RestartDelays
+	crashes int
+}
+
+func newRestartPolicy(options *POptions) *restartPolicy {
+	if options == nil || options.restartDelays == nil {
+		return nil
+	}
+	return &restartPolicy{delays: *options.restartDelays}
+}
+
+// delay returns the delay of the automatic restart after the exit (nil if the exit status is not known),
+// or false if the process should not be restarted. Without the policy, the process is restarted immediately.
+func (rp *restartPolicy) delay(exit *ExitClassification, uptime time.Duration) (time.Duration, bool) {
+	if rp == nil {
+		return 0, true
+	}
+	category := ExitUnknown
+	if exit != nil {
+		category = exit.Category
+	}
+	switch category {
+	case ExitStopped, ExitForceStopped, ExitRestarted:
+		return 0, false
+	case ExitClean:
+		rp.crashes = 0
+		return rp.delays.CleanExit, true
+	}
+	if rp.delays.MaxCrash <= rp.delays.Crash {
+		return rp.delays.Crash, true
+	}
+	if uptime >= rp.delays.MaxCrash {
+		rp.crashes = 0
+	}
+	delay := rp.delays.Crash
+	for i := 0; i < rp.crashes && delay < rp.delays.MaxCrash; i++ {
+		delay *= 2
+	}
+	if delay > rp.delays.MaxCrash {
+		delay = rp.delays.MaxCrash
+	}
+	rp.crashes++
+	return delay, true
+}
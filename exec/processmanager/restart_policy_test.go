@@ -0,0 +1,52 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartPolicy(t *testing.T) {
+	var none *restartPolicy
+	if delay, restart := none.delay(&ExitClassification{Category: ExitStopped}, 0); !restart || delay != 0 {
+		t.Errorf("without policy, process should be restarted immediately")
+	}
+
+	policy := newRestartPolicy(&POptions{restartDelays: &RestartDelays{
+		Crash:     100 * time.Millisecond,
+		MaxCrash:  time.Second,
+		CleanExit: time.Minute,
+	}})
+	crash := &ExitClassification{Category: ExitCrashed}
+	expected := []time.Duration{100, 200, 400, 800, 1000}
+	for i, exp := range expected {
+		if delay, _ := policy.delay(crash, 0); delay != exp*time.Millisecond {
+			t.Errorf("crash %d: expected delay %v, got %v", i, exp*time.Millisecond, delay)
+		}
+	}
+	// process running long enough resets the backoff
+	if delay, _ := policy.delay(nil, time.Second); delay != 100*time.Millisecond {
+		t.Errorf("expected backoff reset, got %v", delay)
+	}
+	if delay, _ := policy.delay(&ExitClassification{Category: ExitClean}, 0); delay != time.Minute {
+		t.Errorf("expected clean exit delay, got %v", delay)
+	}
+	for _, category := range []ExitCategory{ExitStopped, ExitForceStopped, ExitRestarted} {
+		if _, restart := policy.delay(&ExitClassification{Category: category}, 0); restart {
+			t.Errorf("%s process should not be restarted", category)
+		}
+	}
+}
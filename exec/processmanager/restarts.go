@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+// SetRestartPolicy replaces the remaining number of automatic restarts of the process, e.g. zero to disable
+// restarts of a flapping process, or a new budget after fixing the cause of the failures. Use -1 for
+// unlimited restarts. The change applies to the next termination of the process.
+func (p *Process) SetRestartPolicy(restarts int32) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.restartsLeft = restarts
+	p.restartsSet = true
+}
+
+// initRestarts sets the number of restarts from options, unless it was already set with SetRestartPolicy
+func (p *Process) initRestarts(restarts int32) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if !p.restartsSet {
+		p.restartsLeft = restarts
+		p.restartsSet = true
+	}
+}
+
+// takeRestart returns true if the process may be restarted automatically, and consumes one restart
+func (p *Process) takeRestart() bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	switch {
+	case p.restartsLeft == infiniteRestarts:
+		return true
+	case p.restartsLeft > 0:
+		p.restartsLeft--
+		return true
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"time"
+)
+
+// TimeWindow is a daily time window, defined by offsets from the (local) midnight. If End is before Start,
+// the window spans across the midnight (e.g. 22h-4h).
+type TimeWindow struct {
+	// Start of the window as a duration since midnight, e.g. 22*time.Hour
+	Start time.Duration
+	// End of the window as a duration since midnight, e.g. 4*time.Hour
+	End time.Duration
+}
+
+// Contains returns true if given time falls into the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// until returns duration from t to the next start of the window
+func (w TimeWindow) until(t time.Time) time.Duration {
+	wait := w.Start - sinceMidnight(t)
+	if wait < 0 {
+		wait += 24 * time.Hour
+	}
+	return wait
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+}
+
+// restartDelay returns how long the automatic restart has to be deferred at given time according to the restart
+// schedule. Zero is returned if the restart is allowed right away.
+func (p *Process) restartDelay(now time.Time) time.Duration {
+	if p.options == nil || len(p.options.restartWindows) == 0 {
+		return 0
+	}
+	var delay time.Duration
+	for i, window := range p.options.restartWindows {
+		if window.Contains(now) {
+			return 0
+		}
+		if wait := window.until(now); i == 0 || wait < delay {
+			delay = wait
+		}
+	}
+	return delay
+}
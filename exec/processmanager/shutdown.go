@@ -0,0 +1,178 @@
+// Copyright (c) 2018 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processmanager
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultStopTimeout is the time given to the process to terminate during shutdown
+const defaultStopTimeout = 10 * time.Second
+
+// RunUntilSignal blocks until one of given signals (SIGINT and SIGTERM by default) is received or the context
+// is done, then shuts down all managed processes. Groups registered with the plugin (see Plugin.NewProcessGroup)
+// are stopped first as units, in reverse order of their creation, members of every group in reverse order.
+// Remaining processes are then stopped one by one in reverse order of creation, so processes depending
+// on those created earlier are stopped first. Every process is given its stop timeout
+// (see WithStopTimeout) to terminate before it is killed. A second signal received during the shutdown kills all
+// remaining processes immediately.
+func (p *Plugin) RunUntilSignal(ctx context.Context, sigs ...os.Signal) error {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, sigs...)
+	defer signal.Stop(sigChan)
+
+	select {
+	case sig := <-sigChan:
+		p.Log.Infof("Received signal %v, stopping all processes", sig)
+	case <-ctx.Done():
+		p.Log.Infof("Context done, stopping all processes")
+	}
+
+	force := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case sig := <-sigChan:
+			p.Log.Warnf("Received signal %v during shutdown, killing remaining processes", sig)
+			close(force)
+		case <-done:
+		}
+	}()
+	return p.shutdown(force)
+}
+
+// shutdown stops all registered groups and then all remaining processes, both in reverse order of creation.
+// Processes are killed if they do not terminate within their stop timeout or if the force channel is closed.
+func (p *Plugin) shutdown(force <-chan struct{}) error {
+	var failed []string
+	stopped := make(map[*Process]bool)
+	stop := func(pr *Process) {
+		if stopped[pr] {
+			return
+		}
+		stopped[pr] = true
+		pr.setShutdown()
+		if !pr.isAlive() {
+			return
+		}
+		if err := pr.stopWithTimeout(force); err != nil {
+			p.Log.Errorf("failed to stop process %s: %v", pr.name, err)
+			failed = append(failed, pr.name)
+		}
+	}
+	groups := p.listGroups()
+	for i := len(groups) - 1; i >= 0; i-- {
+		p.Log.Debugf("Stopping process group %s", groups[i].GetName())
+		members := groups[i].GetMembers()
+		for j := len(members) - 1; j >= 0; j-- {
+			// members deleted from the plugin are not managed anymore
+			if pr := p.getProcess(members[j].GetName()); pr != nil {
+				stop(pr)
+			}
+		}
+	}
+	processes := p.listProcesses()
+	for i := len(processes) - 1; i >= 0; i-- {
+		stop(processes[i])
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to stop processes: %v", failed)
+	}
+	return nil
+}
+
+// stopWithTimeout stops the process and waits until it terminates. The process is killed after the stop timeout,
+// or immediately if the force channel is closed.
+func (p *Process) stopWithTimeout(force <-chan struct{}) error {
+	select {
+	case <-force:
+		return p.Kill()
+	default:
+	}
+	if err := p.stopProcess(); err != nil {
+		return err
+	}
+
+	timeout := defaultStopTimeout
+	if p.options != nil && p.options.stopTimeout > 0 {
+		timeout = p.options.stopTimeout
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	// reap the child process, or just poll if the process is not a child (attached)
+	waited := make(chan struct{})
+	go func() {
+		p.waitOnProcess()
+		close(waited)
+	}()
+	for {
+		select {
+		case <-waited:
+			waited = nil
+		case <-ticker.C:
+		case <-deadline.C:
+			p.log.Warnf("Process %s did not terminate within %v, killing it", p.name, timeout)
+			return p.killAndWait(waited)
+		case <-force:
+			return p.killAndWait(waited)
+		}
+		if waited == nil && !p.isAlive() {
+			p.log.Debugf("Process %s stopped", p.name)
+			return nil
+		}
+	}
+}
+
+// killAndWait kills the process and waits (for a limited time) until it is reaped. Unlike Kill, it does not
+// release the process, so that the waiting goroutine can reap it and record its exit.
+func (p *Process) killAndWait(waited <-chan struct{}) error {
+	p.setStopReason(StopReasonKill)
+	if err := p.osProcess().Signal(syscall.SIGKILL); err != nil && !strings.Contains(err.Error(), alreadyFinished) {
+		return errors.Errorf("process forced termination unsuccessful: %v", err)
+	}
+	if waited != nil {
+		select {
+		case <-waited:
+		case <-time.After(time.Second):
+		}
+	}
+	return nil
+}
+
+func (p *Process) setShutdown() {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.shutdown = true
+}
+
+func (p *Process) isShutdown() bool {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.shutdown
+}
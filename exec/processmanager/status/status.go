@@ -37,8 +37,15 @@ const (
 
 	// Plugin-defined process statuses (as addition to other process statuses)
 	Initial     = "initial"     // Only for newly created/attached processes
+	NotStarted  = "not-started" // Process created with WithStartStopped option, which was not started yet
+	Starting    = "starting"    // Process is running but did not pass its readiness probe yet
 	Unavailable = "unavailable" // If process status cannot be obtained
 	Terminated  = "terminated"  // If process is not running (while tested by zero signal)
+
+	// Plugin-defined process events
+	RestartDeferred  = "restart-deferred"  // Automatic restart was deferred until the next restart window
+	FDLimitWarning   = "fd-limit-warning"  // Number of open file descriptors approaches the limit
+	DeadlineExceeded = "deadline-exceeded" // Lifecycle deadline elapsed, the process was stopped for good
 )
 
 // ProcessStatus is string representation of process status
@@ -0,0 +1,75 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logging
+
+import (
+	"os"
+	"runtime"
+	"sync"
+)
+
+// StartupMessage is the message of the entry logged by LogStartupBanner
+const StartupMessage = "service started"
+
+// StartupInfo describes the started service for LogStartupBanner
+type StartupInfo struct {
+	// Name of the service, defaults to the executable name
+	Name string
+	// Version, Commit and BuildDate of the build (optional)
+	Version   string
+	Commit    string
+	BuildDate string
+}
+
+var startupOnce sync.Once
+
+// LogStartupBanner logs a single structured entry at Info level marking the start of the process, with
+// the service version, commit, Go version, host name, PID and command line arguments. Only the first
+// call logs the entry, further calls do nothing. Field values are only strings, numbers and the list
+// of arguments, so the entry is rendered correctly by any formatter.
+func LogStartupBanner(l Logger, info StartupInfo) {
+	startupOnce.Do(func() {
+		l.WithFields(startupFields(info)).Info(StartupMessage)
+	})
+}
+
+func startupFields(info StartupInfo) Fields {
+	name := info.Name
+	if name == "" && len(os.Args) > 0 {
+		name = os.Args[0]
+	}
+	host, _ := os.Hostname()
+	args := []string{}
+	if len(os.Args) > 1 {
+		args = os.Args[1:]
+	}
+	fields := Fields{
+		"service":    name,
+		"go-version": runtime.Version(),
+		"host":       host,
+		"pid":        os.Getpid(),
+		"args":       args,
+	}
+	if info.Version != "" {
+		fields["version"] = info.Version
+	}
+	if info.Commit != "" {
+		fields["commit"] = info.Commit
+	}
+	if info.BuildDate != "" {
+		fields["build-date"] = info.BuildDate
+	}
+	return fields
+}
@@ -0,0 +1,284 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Names of formatters registered by default, see RegisterFormatter
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config is a declarative configuration of logging, which can be applied
+// to a registry using Apply, e.g. after loading it from a YAML file.
+type Config struct {
+	// DefaultLevel is the level for loggers without explicit level
+	DefaultLevel string `json:"default-level"`
+	// Loggers maps logger names to their levels, which apply also to descendants (see Registry.SetLevel)
+	Loggers map[string]string `json:"loggers"`
+	// Format of log output: name of registered formatter, e.g. "text" or "json" (empty keeps the current formatter)
+	Format string `json:"format"`
+	// Output is "stdout", "stderr" or a file path (empty keeps the current output)
+	Output string `json:"output"`
+	// Rotation of the output file (ignored for stdout/stderr)
+	Rotation RotationConfig `json:"rotation"`
+	// OriginFields adds host and pid fields to all log entries
+	OriginFields bool `json:"origin-fields"`
+	// Host overrides value of the host field (e.g. with pod name), defaults to the host name
+	Host string `json:"host"`
+}
+
+// RotationConfig defines size-based rotation of the log file.
+type RotationConfig struct {
+	// MaxSize is the size in megabytes after which the file is rotated (0 disables rotation)
+	MaxSize int `json:"max-size"`
+	// MaxBackups is the number of rotated files to keep (<file>.1 being the newest)
+	MaxBackups int `json:"max-backups"`
+}
+
+// OutputSetter is implemented by registries which can change output
+// of all (including future) loggers.
+type OutputSetter interface {
+	SetOutput(out io.Writer)
+}
+
+// FormatterSetter is implemented by registries which can change formatter
+// of all (including future) loggers.
+type FormatterSetter interface {
+	SetFormatter(formatter logrus.Formatter)
+}
+
+// OriginSetter is implemented by registries which can add host and pid fields
+// to entries of all (including future) loggers.
+type OriginSetter interface {
+	SetOriginFields(host string)
+}
+
+// AppliedOutputHolder is implemented by registries which keep the output file opened by Apply,
+// so that it is closed once a later Apply replaces it. Registries without it never close
+// the output files opened by Apply.
+type AppliedOutputHolder interface {
+	// SwapAppliedOutput stores the output and returns the previously stored one (if any)
+	SwapAppliedOutput(out io.Closer) (prev io.Closer)
+}
+
+// Apply configures the registry and its live loggers according to the config. It can be called
+// repeatedly to apply changed configuration. Loggers not listed in cfg.Loggers are set to the
+// default level. Format and output are applied to all loggers, and also to loggers created
+// later if the registry implements FormatterSetter and OutputSetter.
+func Apply(cfg Config, reg Registry) (err error) {
+	var defaultLevel LogLevel
+	if cfg.DefaultLevel != "" {
+		lvl, err := ParseLogLevel(cfg.DefaultLevel)
+		if err != nil {
+			return fmt.Errorf("invalid default level: %v", err)
+		}
+		defaultLevel = lvl
+	}
+	levels := make(map[string]LogLevel, len(cfg.Loggers))
+	for name, level := range cfg.Loggers {
+		lvl, err := ParseLogLevel(level)
+		if err != nil {
+			return fmt.Errorf("invalid level for logger %q: %v", name, err)
+		}
+		levels[name] = lvl
+	}
+	var formatter Formatter
+	if cfg.Format != "" {
+		f, err := formatterByName(cfg.Format)
+		if err != nil {
+			return err
+		}
+		formatter = f
+	}
+	out, err := openOutput(cfg.Output, cfg.Rotation)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// the output is not used by any logger yet
+		if file, ok := out.(*rotatingFile); ok && err != nil {
+			file.Close()
+		}
+	}()
+
+	if cfg.DefaultLevel != "" {
+		if err := reg.SetLevel("default", cfg.DefaultLevel); err != nil {
+			return err
+		}
+	}
+	for name, level := range cfg.Loggers {
+		if err := reg.SetLevel(name, level); err != nil {
+			return err
+		}
+	}
+	for name := range reg.ListLoggers() {
+		logger, found := reg.Lookup(name)
+		if !found {
+			continue
+		}
+		if !hasLevel(levels, name) && cfg.DefaultLevel != "" {
+			logger.SetLevel(defaultLevel)
+		}
+		if formatter != nil {
+			logger.SetFormatter(formatter)
+		}
+		if out != nil {
+			logger.SetOutput(out)
+		}
+	}
+	if setter, ok := reg.(OriginSetter); ok && cfg.OriginFields {
+		setter.SetOriginFields(cfg.Host)
+	}
+	if fs, ok := reg.(FormatterSetter); ok && formatter != nil {
+		fs.SetFormatter(formatter)
+	}
+	if out != nil {
+		if setter, ok := reg.(OutputSetter); ok {
+			setter.SetOutput(out)
+		}
+		// close file opened by previous Apply
+		// (stdout and stderr are never closed)
+		if holder, ok := reg.(AppliedOutputHolder); ok {
+			var file io.Closer
+			if f, ok := out.(*rotatingFile); ok {
+				file = f
+			}
+			if prev := holder.SwapAppliedOutput(file); prev != nil {
+				prev.Close()
+			}
+		}
+	}
+	return nil
+}
+
+func openOutput(output string, rotation RotationConfig) (io.Writer, error) {
+	switch output {
+	case "":
+		return nil, nil
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	w := &rotatingFile{
+		path:       output,
+		maxSize:    int64(rotation.MaxSize) * 1024 * 1024,
+		maxBackups: rotation.MaxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// rotatingFile is a file writer which rotates the file after it reaches the maximum size
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func (w *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return fmt.Errorf("cannot create log directory: %v", err)
+	}
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot open log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("cannot stat log file: %v", err)
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+func (w *rotatingFile) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if w.maxBackups <= 0 {
+		if err := os.Remove(w.path); err != nil {
+			return err
+		}
+		return w.open()
+	}
+	for i := w.maxBackups - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// Write writes p into the file, rotating it first if it would exceed the maximum size.
+func (w *rotatingFile) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, fmt.Errorf("log file rotation failed: %v", err)
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (w *rotatingFile) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// hasLevel returns true if the level is set for the logger or its ancestor in the hierarchy of dotted names
+func hasLevel(levels map[string]LogLevel, name string) bool {
+	for {
+		if _, ok := levels[name]; ok {
+			return true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return false
+		}
+		name = name[:i]
+	}
+}
@@ -14,4 +14,12 @@
 
 // Package logging defines the logging API. It used by all plugins, and supports
 // multiple log levels (severities) and various log message formats.
+//
+// Log calls on a disabled level return immediately, but arguments passed through
+// the Logger interface may still be allocated by the caller (boxing into the variadic
+// ...interface{}). In hot paths, guard expensive log calls with IsLevelEnabled:
+//
+//	if logging.IsLevelEnabled(log, logging.DebugLevel) {
+//		log.Debugf("received %v", msg)
+//	}
 package logging
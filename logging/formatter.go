@@ -0,0 +1,78 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Formatter renders a log entry into bytes written to the log output. It is satisfied by
+// all logrus formatters.
+type Formatter interface {
+	Format(entry *logrus.Entry) ([]byte, error)
+}
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = map[string]Formatter{}
+)
+
+func init() {
+	RegisterFormatter(FormatText, &logrus.TextFormatter{EnvironmentOverrideColors: true})
+	RegisterFormatter(FormatJSON, &logrus.JSONFormatter{})
+}
+
+// RegisterFormatter registers the formatter under the name, so that it can be selected by
+// Config.Format. Registering formatter with a name already registered replaces it.
+func RegisterFormatter(name string, formatter Formatter) {
+	if name == "" || formatter == nil {
+		panic("logging: formatter must have a name and cannot be nil")
+	}
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[name] = formatter
+}
+
+// LookupFormatter returns the formatter registered under the name.
+func LookupFormatter(name string) (Formatter, bool) {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	f, ok := formatters[name]
+	return f, ok
+}
+
+// RegisteredFormatters returns sorted names of all registered formatters.
+func RegisteredFormatters() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatterByName(name string) (Formatter, error) {
+	f, ok := LookupFormatter(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown log format %q (registered: %v)", name, RegisteredFormatters())
+	}
+	return f, nil
+}
@@ -0,0 +1,100 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logging
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DefaultHeartbeatInterval is used by StartRuntimeHeartbeat if the given interval is not positive
+const DefaultHeartbeatInterval = time.Minute
+
+// StartRuntimeHeartbeat periodically logs runtime statistics (goroutine count,
+// heap usage and GC pauses) using given logger at the given level. Logging is
+// done in a separate goroutine until the returned stop function is called.
+// Calling stop multiple times is safe. Non-positive interval is replaced with
+// DefaultHeartbeatInterval and fatal or panic level with error level, since
+// those would end the program.
+func StartRuntimeHeartbeat(l Logger, interval time.Duration, level LogLevel) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	if level < ErrorLevel {
+		level = ErrorLevel
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				logAtLevel(l.WithFields(runtimeStats()), level, "runtime heartbeat")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+}
+
+// runtimeStats returns current runtime statistics as log fields
+func runtimeStats() Fields {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	var lastPause time.Duration
+	if ms.NumGC > 0 {
+		lastPause = time.Duration(ms.PauseNs[(ms.NumGC+255)%256])
+	}
+
+	return Fields{
+		"goroutines":     runtime.NumGoroutine(),
+		"heap-alloc":     ms.HeapAlloc,
+		"heap-inuse":     ms.HeapInuse,
+		"heap-objects":   ms.HeapObjects,
+		"sys":            ms.Sys,
+		"num-gc":         ms.NumGC,
+		"gc-pause-last":  lastPause.String(),
+		"gc-pause-total": time.Duration(ms.PauseTotalNs).String(),
+	}
+}
+
+// logAtLevel logs message using the log method corresponding to the level,
+// fatal and panic levels are not supported
+func logAtLevel(l LogWithLevel, level LogLevel, msg string) {
+	switch level {
+	case TraceLevel:
+		l.Trace(msg)
+	case DebugLevel:
+		l.Debug(msg)
+	case InfoLevel:
+		l.Info(msg)
+	case WarnLevel:
+		l.Warn(msg)
+	case ErrorLevel:
+		l.Error(msg)
+	}
+}
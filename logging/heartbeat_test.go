@@ -0,0 +1,82 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logging_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.ligato.io/cn-infra/v2/logging"
+	"go.ligato.io/cn-infra/v2/logging/logrus"
+)
+
+// syncBuffer is a buffer safe for concurrent use by the heartbeat goroutine and the test
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRuntimeHeartbeat(t *testing.T) {
+	log := logrus.NewLogger("heartbeat-test")
+	var out syncBuffer
+	log.SetOutput(&out)
+	log.SetLevel(logging.DebugLevel)
+
+	stop := logging.StartRuntimeHeartbeat(log, 10*time.Millisecond, logging.DebugLevel)
+	waitForHeartbeat(t, &out)
+	if !strings.Contains(out.String(), "goroutines") {
+		t.Errorf("runtime stats not logged: %q", out.String())
+	}
+	stop()
+	stop()
+
+	// panic level is logged as error instead of panicking
+	var clamped syncBuffer
+	log.SetOutput(&clamped)
+	stop = logging.StartRuntimeHeartbeat(log, 10*time.Millisecond, logging.PanicLevel)
+	defer stop()
+	waitForHeartbeat(t, &clamped)
+	if !strings.Contains(clamped.String(), "level=error") {
+		t.Errorf("heartbeat not logged at error level: %q", clamped.String())
+	}
+
+	// non-positive interval falls back to the default instead of panicking
+	logging.StartRuntimeHeartbeat(log, 0, logging.InfoLevel)()
+}
+
+func waitForHeartbeat(t *testing.T, out *syncBuffer) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "runtime heartbeat") {
+		if time.Now().After(deadline) {
+			t.Fatalf("heartbeat not logged: %q", out.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -115,6 +115,28 @@ type Logger interface {
 	SetFormatter(formatter logrus.Formatter)
 }
 
+// LevelChecker is implemented by loggers which can cheaply report whether a level is enabled
+type LevelChecker interface {
+	// IsLevelEnabled returns true if entries with given level are logged
+	IsLevelEnabled(level LogLevel) bool
+}
+
+// IsLevelEnabled returns true if entries with given level are logged by the logger. Use it
+// to guard log calls in hot paths when called through the Logger interface, since the
+// arguments of variadic methods are allocated by the caller even if the level is disabled:
+//
+//	if logging.IsLevelEnabled(log, logging.DebugLevel) {
+//		log.Debugf("processed %d items", n)
+//	}
+//
+// Loggers which do not implement LevelChecker are checked against their current level.
+func IsLevelEnabled(log Logger, level LogLevel) bool {
+	if checker, ok := log.(LevelChecker); ok {
+		return checker.IsLevelEnabled(level)
+	}
+	return log.GetLevel() >= level
+}
+
 // LoggerFactory is API for the plugins that want to create their own loggers.
 type LoggerFactory interface {
 	NewLogger(name string) Logger
@@ -126,7 +148,8 @@ type Registry interface {
 
 	// ListLoggers returns a map (loggerName => log level)
 	ListLoggers() map[string]string
-	// SetLevel modifies log level of selected logger in the registry
+	// SetLevel modifies log level of selected logger in the registry, and of its descendants in the
+	// hierarchy of dotted logger names (e.g. "app.grpc" for "app") without their own level
 	SetLevel(logger, level string) error
 	// GetLevel returns the currently set log level of the logger from registry
 	GetLevel(logger string) (string, error)
@@ -30,4 +30,6 @@ type HookConfig struct {
 	Address  string
 	Port     int
 	Levels   []string
+	// Fallback receives entries the hook failed to deliver: "stderr", "stdout" or a file path
+	Fallback string
 }
@@ -13,5 +13,5 @@
 // limitations under the License.
 
 // Package logmanager implements the log manager that allows users to set
-// log levels at run-time via a REST API.
+// log levels at run-time via a REST API, or via gRPC (see RegisterLogAdminServer).
 package logmanager
@@ -0,0 +1,74 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+//go:generate protoc --proto_path=model/logadmin --go_out=plugins=grpc:model/logadmin model/logadmin/logadmin.proto
+
+package logmanager
+
+import (
+	"context"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.ligato.io/cn-infra/v2/logging"
+	"go.ligato.io/cn-infra/v2/logging/logmanager/model/logadmin"
+)
+
+// LogAdminServer implements the LogAdmin gRPC service managing log levels of loggers in the registry.
+// It complements the HTTP API of the plugin where only the gRPC port is exposed.
+type LogAdminServer struct {
+	Registry logging.Registry
+}
+
+// RegisterLogAdminServer registers the LogAdmin service for the registry on the gRPC server,
+// e.g. the one shared by plugins (see grpc.Plugin.GetServer).
+func RegisterLogAdminServer(srv *grpc.Server, registry logging.Registry) {
+	logadmin.RegisterLogAdminServer(srv, &LogAdminServer{Registry: registry})
+}
+
+// ListLoggers returns all registered loggers with their levels, ordered by name.
+func (s *LogAdminServer) ListLoggers(ctx context.Context, req *logadmin.ListLoggersRequest) (*logadmin.ListLoggersResponse, error) {
+	resp := &logadmin.ListLoggersResponse{}
+	for name, level := range s.Registry.ListLoggers() {
+		resp.Loggers = append(resp.Loggers, &logadmin.Logger{Name: name, Level: level})
+	}
+	sort.Slice(resp.Loggers, func(i, j int) bool {
+		return resp.Loggers[i].Name < resp.Loggers[j].Name
+	})
+	return resp, nil
+}
+
+// GetLevel returns the level of the logger, or NotFound error if the logger does not exist.
+func (s *LogAdminServer) GetLevel(ctx context.Context, req *logadmin.GetLevelRequest) (*logadmin.GetLevelResponse, error) {
+	level, err := s.Registry.GetLevel(req.GetLogger())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &logadmin.GetLevelResponse{Level: level}, nil
+}
+
+// SetLevel sets the level of the logger and its descendants, or returns InvalidArgument error
+// if the level is not valid.
+func (s *LogAdminServer) SetLevel(ctx context.Context, req *logadmin.SetLevelRequest) (*logadmin.SetLevelResponse, error) {
+	if _, err := logging.ParseLogLevel(req.GetLevel()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.Registry.SetLevel(req.GetLogger(), req.GetLevel()); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &logadmin.SetLevelResponse{}, nil
+}
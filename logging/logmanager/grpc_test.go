@@ -0,0 +1,78 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logmanager_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"go.ligato.io/cn-infra/v2/logging/logmanager"
+	"go.ligato.io/cn-infra/v2/logging/logmanager/model/logadmin"
+	"go.ligato.io/cn-infra/v2/logging/logrus"
+)
+
+func TestLogAdminServer(t *testing.T) {
+	registry := logrus.NewLogRegistry()
+	registry.NewLogger("admin-test")
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	logmanager.RegisterLogAdminServer(srv, registry)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := logadmin.NewLogAdminClient(conn)
+	ctx := context.Background()
+
+	if _, err := client.SetLevel(ctx, &logadmin.SetLevelRequest{Logger: "admin-test", Level: "debug"}); err != nil {
+		t.Fatalf("set level failed: %v", err)
+	}
+	resp, err := client.GetLevel(ctx, &logadmin.GetLevelRequest{Logger: "admin-test"})
+	if err != nil || resp.Level != "debug" {
+		t.Errorf("expected debug level, got %v (err: %v)", resp, err)
+	}
+	list, err := client.ListLoggers(ctx, &logadmin.ListLoggersRequest{})
+	if err != nil {
+		t.Fatalf("list loggers failed: %v", err)
+	}
+	var found bool
+	for _, logger := range list.Loggers {
+		found = found || (logger.Name == "admin-test" && logger.Level == "debug")
+	}
+	if !found {
+		t.Errorf("logger not listed: %v", list.Loggers)
+	}
+
+	if _, err := client.SetLevel(ctx, &logadmin.SetLevelRequest{Logger: "admin-test", Level: "loud"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for invalid level, got %v", err)
+	}
+	if _, err := client.GetLevel(ctx, &logadmin.GetLevelRequest{Logger: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for missing logger, got %v", err)
+	}
+}
@@ -2,8 +2,11 @@ package logmanager
 
 import (
 	"fmt"
+	"io"
 	"log/syslog"
+	"os"
 	"strconv"
+	"sync"
 
 	"github.com/bshuster-repo/logrus-logstash-hook"
 	"github.com/evalphobia/logrus_fluent"
@@ -18,10 +21,32 @@ const (
 	HookFluent   = "fluent"
 )
 
+// Fallback sink names usable in hook configuration
+const (
+	FallbackStderr = "stderr"
+	FallbackStdout = "stdout"
+)
+
+// SinkErrorHandler is called when a hook (log sink) fails to deliver a log entry
+type SinkErrorHandler func(hookName string, entry *logrus.Entry, err error)
+
+// defaultSinkErrorHandler reports sink failures to stderr as a last resort
+func defaultSinkErrorHandler(hookName string, entry *logrus.Entry, err error) {
+	fmt.Fprintf(os.Stderr, "log sink %s failed to deliver entry %q: %v\n", hookName, entry.Message, err)
+}
+
 // commonHook implements that Hook with own level definition
 type commonHook struct {
 	logrus.Hook
+	name   string
 	levels []logrus.Level
+
+	// sink failure handling
+	onError  SinkErrorHandler
+	fallback io.Writer
+	closer   io.Closer // fallback file opened for the hook
+	mx       sync.Mutex
+	failures uint64
 }
 
 // Levels overrides implementation from embedded interface
@@ -29,6 +54,94 @@ func (cH *commonHook) Levels() []logrus.Level {
 	return cH.levels
 }
 
+// Fire overrides implementation from embedded interface. Delivery errors are not
+// swallowed, but reported via the error handler and the entry is written to the
+// fallback sink (if defined).
+func (cH *commonHook) Fire(entry *logrus.Entry) error {
+	err := cH.Hook.Fire(entry)
+	if err == nil {
+		return nil
+	}
+	cH.mx.Lock()
+	cH.failures++
+	cH.mx.Unlock()
+
+	if cH.onError != nil {
+		cH.onError(cH.name, entry, err)
+	}
+	cH.mx.Lock()
+	defer cH.mx.Unlock()
+	if cH.fallback != nil {
+		line, ferr := entry.String()
+		if ferr == nil {
+			_, ferr = io.WriteString(cH.fallback, line)
+		}
+		if ferr != nil {
+			return fmt.Errorf("hook %s failed: %v (fallback failed: %v)", cH.name, err, ferr)
+		}
+	}
+	return nil
+}
+
+// close stops writing to the fallback sink and closes it if it was opened for the hook
+func (cH *commonHook) close() error {
+	cH.mx.Lock()
+	defer cH.mx.Unlock()
+	cH.fallback = nil
+	if cH.closer == nil {
+		return nil
+	}
+	err := cH.closer.Close()
+	cH.closer = nil
+	return err
+}
+
+// Failures returns number of entries the hook failed to deliver
+func (cH *commonHook) Failures() uint64 {
+	cH.mx.Lock()
+	defer cH.mx.Unlock()
+	return cH.failures
+}
+
+// returns fallback writer for given configuration value (stderr, stdout or file path), the closer
+// is returned only for files opened by this function
+func fallbackWriter(fallback string) (io.Writer, io.Closer, error) {
+	switch fallback {
+	case "":
+		return nil, nil, nil
+	case FallbackStderr:
+		return os.Stderr, nil, nil
+	case FallbackStdout:
+		return os.Stdout, nil, nil
+	default:
+		file, err := os.OpenFile(fallback, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return file, file, nil
+	}
+}
+
+// closes fallback sinks opened for the hooks
+func (p *Plugin) closeHooks() error {
+	var wasErr error
+	for _, hook := range p.hooks {
+		if err := hook.close(); err != nil && wasErr == nil {
+			wasErr = fmt.Errorf("closing fallback sink of %v failed: %v", hook.name, err)
+		}
+	}
+	return wasErr
+}
+
+// SinkFailures returns number of log entries each configured hook failed to deliver
+func (p *Plugin) SinkFailures() map[string]uint64 {
+	failures := make(map[string]uint64, len(p.hooks))
+	for _, hook := range p.hooks {
+		failures[hook.name] = hook.Failures()
+	}
+	return failures
+}
+
 // store hook into registy for late use and applies to existing loggers
 func (p *Plugin) addHook(hookName string, hookConfig HookConfig) error {
 	var lgHook logrus.Hook
@@ -65,7 +178,21 @@ func (p *Plugin) addHook(hookName string, hookConfig HookConfig) error {
 		return fmt.Errorf("creating hook for %v failed: %v", hookName, err)
 	}
 	// create hook
-	cHook := &commonHook{Hook: lgHook}
+	cHook := &commonHook{
+		Hook:     lgHook,
+		name:     hookName,
+		onError:  p.sinkErrorHandler,
+		fallback: p.fallbackSink,
+	}
+	if cHook.onError == nil {
+		cHook.onError = defaultSinkErrorHandler
+	}
+	if hookConfig.Fallback != "" {
+		if cHook.fallback, cHook.closer, err = fallbackWriter(hookConfig.Fallback); err != nil {
+			return fmt.Errorf("opening fallback sink for %v failed: %v", hookName, err)
+		}
+	}
+	p.hooks = append(p.hooks, cHook)
 	// fill up defined levels, or use default if not defined
 	if len(hookConfig.Levels) == 0 {
 		cHook.levels = []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
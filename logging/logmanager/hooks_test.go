@@ -0,0 +1,79 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logmanager
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// failingHook fails to deliver every entry
+type failingHook struct{}
+
+func (failingHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (failingHook) Fire(*logrus.Entry) error { return errors.New("sink unavailable") }
+
+func TestHookFallbackClosed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logmanager-fallback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fallback.log")
+
+	hook := &commonHook{Hook: failingHook{}, name: "failing"}
+	if hook.fallback, hook.closer, err = fallbackWriter(path); err != nil {
+		t.Fatalf("opening fallback failed: %v", err)
+	}
+	p := &Plugin{hooks: []*commonHook{hook}}
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	if err := hook.Fire(logrus.NewEntry(logger).WithField("k", "v")); err != nil {
+		t.Fatalf("fallback write failed: %v", err)
+	}
+	if hook.Failures() != 1 {
+		t.Errorf("expected 1 failure, got %d", hook.Failures())
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "k=v") {
+		t.Errorf("entry not written to fallback: %q", data)
+	}
+
+	file := hook.closer.(*os.File)
+	if err := p.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, err := file.Write([]byte("x")); err == nil {
+		t.Errorf("fallback file was not closed")
+	}
+	// entries failing after close are not written to the closed file
+	if err := hook.Fire(logrus.NewEntry(logger)); err != nil {
+		t.Errorf("unexpected error after close: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("repeated close failed: %v", err)
+	}
+}
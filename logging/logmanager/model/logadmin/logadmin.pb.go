@@ -0,0 +1,490 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: logadmin.proto
+
+// Package logadmin provides gRPC service for remote management of log levels.
+
+package logadmin
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Logger struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Level                string   `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Logger) Reset()         { *m = Logger{} }
+func (m *Logger) String() string { return proto.CompactTextString(m) }
+func (*Logger) ProtoMessage()    {}
+func (*Logger) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2ce0a43b2055f3, []int{0}
+}
+
+func (m *Logger) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Logger.Unmarshal(m, b)
+}
+func (m *Logger) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Logger.Marshal(b, m, deterministic)
+}
+func (m *Logger) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Logger.Merge(m, src)
+}
+func (m *Logger) XXX_Size() int {
+	return xxx_messageInfo_Logger.Size(m)
+}
+func (m *Logger) XXX_DiscardUnknown() {
+	xxx_messageInfo_Logger.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Logger proto.InternalMessageInfo
+
+func (m *Logger) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Logger) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+type ListLoggersRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListLoggersRequest) Reset()         { *m = ListLoggersRequest{} }
+func (m *ListLoggersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListLoggersRequest) ProtoMessage()    {}
+func (*ListLoggersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2ce0a43b2055f3, []int{1}
+}
+
+func (m *ListLoggersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListLoggersRequest.Unmarshal(m, b)
+}
+func (m *ListLoggersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListLoggersRequest.Marshal(b, m, deterministic)
+}
+func (m *ListLoggersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListLoggersRequest.Merge(m, src)
+}
+func (m *ListLoggersRequest) XXX_Size() int {
+	return xxx_messageInfo_ListLoggersRequest.Size(m)
+}
+func (m *ListLoggersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListLoggersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListLoggersRequest proto.InternalMessageInfo
+
+type ListLoggersResponse struct {
+	Loggers              []*Logger `protobuf:"bytes,1,rep,name=loggers,proto3" json:"loggers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ListLoggersResponse) Reset()         { *m = ListLoggersResponse{} }
+func (m *ListLoggersResponse) String() string { return proto.CompactTextString(m) }
+func (*ListLoggersResponse) ProtoMessage()    {}
+func (*ListLoggersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2ce0a43b2055f3, []int{2}
+}
+
+func (m *ListLoggersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListLoggersResponse.Unmarshal(m, b)
+}
+func (m *ListLoggersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListLoggersResponse.Marshal(b, m, deterministic)
+}
+func (m *ListLoggersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListLoggersResponse.Merge(m, src)
+}
+func (m *ListLoggersResponse) XXX_Size() int {
+	return xxx_messageInfo_ListLoggersResponse.Size(m)
+}
+func (m *ListLoggersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListLoggersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListLoggersResponse proto.InternalMessageInfo
+
+func (m *ListLoggersResponse) GetLoggers() []*Logger {
+	if m != nil {
+		return m.Loggers
+	}
+	return nil
+}
+
+type GetLevelRequest struct {
+	Logger               string   `protobuf:"bytes,1,opt,name=logger,proto3" json:"logger,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetLevelRequest) Reset()         { *m = GetLevelRequest{} }
+func (m *GetLevelRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLevelRequest) ProtoMessage()    {}
+func (*GetLevelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2ce0a43b2055f3, []int{3}
+}
+
+func (m *GetLevelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLevelRequest.Unmarshal(m, b)
+}
+func (m *GetLevelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLevelRequest.Marshal(b, m, deterministic)
+}
+func (m *GetLevelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLevelRequest.Merge(m, src)
+}
+func (m *GetLevelRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLevelRequest.Size(m)
+}
+func (m *GetLevelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLevelRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLevelRequest proto.InternalMessageInfo
+
+func (m *GetLevelRequest) GetLogger() string {
+	if m != nil {
+		return m.Logger
+	}
+	return ""
+}
+
+type GetLevelResponse struct {
+	Level                string   `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetLevelResponse) Reset()         { *m = GetLevelResponse{} }
+func (m *GetLevelResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLevelResponse) ProtoMessage()    {}
+func (*GetLevelResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2ce0a43b2055f3, []int{4}
+}
+
+func (m *GetLevelResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLevelResponse.Unmarshal(m, b)
+}
+func (m *GetLevelResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLevelResponse.Marshal(b, m, deterministic)
+}
+func (m *GetLevelResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLevelResponse.Merge(m, src)
+}
+func (m *GetLevelResponse) XXX_Size() int {
+	return xxx_messageInfo_GetLevelResponse.Size(m)
+}
+func (m *GetLevelResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLevelResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLevelResponse proto.InternalMessageInfo
+
+func (m *GetLevelResponse) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+type SetLevelRequest struct {
+	Logger               string   `protobuf:"bytes,1,opt,name=logger,proto3" json:"logger,omitempty"`
+	Level                string   `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetLevelRequest) Reset()         { *m = SetLevelRequest{} }
+func (m *SetLevelRequest) String() string { return proto.CompactTextString(m) }
+func (*SetLevelRequest) ProtoMessage()    {}
+func (*SetLevelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2ce0a43b2055f3, []int{5}
+}
+
+func (m *SetLevelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetLevelRequest.Unmarshal(m, b)
+}
+func (m *SetLevelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetLevelRequest.Marshal(b, m, deterministic)
+}
+func (m *SetLevelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetLevelRequest.Merge(m, src)
+}
+func (m *SetLevelRequest) XXX_Size() int {
+	return xxx_messageInfo_SetLevelRequest.Size(m)
+}
+func (m *SetLevelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetLevelRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetLevelRequest proto.InternalMessageInfo
+
+func (m *SetLevelRequest) GetLogger() string {
+	if m != nil {
+		return m.Logger
+	}
+	return ""
+}
+
+func (m *SetLevelRequest) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+type SetLevelResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetLevelResponse) Reset()         { *m = SetLevelResponse{} }
+func (m *SetLevelResponse) String() string { return proto.CompactTextString(m) }
+func (*SetLevelResponse) ProtoMessage()    {}
+func (*SetLevelResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2ce0a43b2055f3, []int{6}
+}
+
+func (m *SetLevelResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetLevelResponse.Unmarshal(m, b)
+}
+func (m *SetLevelResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetLevelResponse.Marshal(b, m, deterministic)
+}
+func (m *SetLevelResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetLevelResponse.Merge(m, src)
+}
+func (m *SetLevelResponse) XXX_Size() int {
+	return xxx_messageInfo_SetLevelResponse.Size(m)
+}
+func (m *SetLevelResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetLevelResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetLevelResponse proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*Logger)(nil), "logadmin.Logger")
+	proto.RegisterType((*ListLoggersRequest)(nil), "logadmin.ListLoggersRequest")
+	proto.RegisterType((*ListLoggersResponse)(nil), "logadmin.ListLoggersResponse")
+	proto.RegisterType((*GetLevelRequest)(nil), "logadmin.GetLevelRequest")
+	proto.RegisterType((*GetLevelResponse)(nil), "logadmin.GetLevelResponse")
+	proto.RegisterType((*SetLevelRequest)(nil), "logadmin.SetLevelRequest")
+	proto.RegisterType((*SetLevelResponse)(nil), "logadmin.SetLevelResponse")
+}
+
+func init() { proto.RegisterFile("logadmin.proto", fileDescriptor_0d2ce0a43b2055f3) }
+
+var fileDescriptor_0d2ce0a43b2055f3 = []byte{
+	// 250 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0xcb, 0xc9, 0x4f, 0x4f,
+	0x4c, 0xc9, 0xcd, 0xcc, 0xd3, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x80, 0xf1, 0x95, 0x8c,
+	0xb8, 0xd8, 0x7c, 0xf2, 0xd3, 0xd3, 0x53, 0x8b, 0x84, 0x84, 0xb8, 0x58, 0xf2, 0x12, 0x73, 0x53,
+	0x25, 0x18, 0x15, 0x18, 0x35, 0x38, 0x83, 0xc0, 0x6c, 0x21, 0x11, 0x2e, 0xd6, 0x9c, 0xd4, 0xb2,
+	0xd4, 0x1c, 0x09, 0x26, 0xb0, 0x20, 0x84, 0xa3, 0x24, 0xc2, 0x25, 0xe4, 0x93, 0x59, 0x5c, 0x02,
+	0xd1, 0x57, 0x1c, 0x94, 0x5a, 0x58, 0x9a, 0x5a, 0x5c, 0xa2, 0xe4, 0xc8, 0x25, 0x8c, 0x22, 0x5a,
+	0x5c, 0x90, 0x9f, 0x57, 0x9c, 0x2a, 0xa4, 0xc5, 0xc5, 0x9e, 0x03, 0x11, 0x92, 0x60, 0x54, 0x60,
+	0xd6, 0xe0, 0x36, 0x12, 0xd0, 0x83, 0x3b, 0x06, 0xa2, 0x36, 0x08, 0xa6, 0x40, 0x49, 0x93, 0x8b,
+	0xdf, 0x3d, 0xb5, 0xc4, 0x07, 0x64, 0x09, 0xd4, 0x54, 0x21, 0x31, 0x2e, 0x36, 0x88, 0x2c, 0xd4,
+	0x5d, 0x50, 0x9e, 0x92, 0x06, 0x97, 0x00, 0x42, 0x29, 0xd4, 0x2a, 0xb8, 0x6b, 0x19, 0x91, 0x5d,
+	0x6b, 0xcf, 0xc5, 0x1f, 0x4c, 0x9c, 0xa1, 0x38, 0xbc, 0x2b, 0xc4, 0x25, 0x10, 0x8c, 0x66, 0x95,
+	0xd1, 0x1d, 0x46, 0x2e, 0x0e, 0x9f, 0xfc, 0x74, 0x47, 0x90, 0x37, 0x84, 0xbc, 0xb8, 0xb8, 0x91,
+	0x7c, 0x2e, 0x24, 0x83, 0xe4, 0x41, 0x8c, 0x60, 0x92, 0x92, 0xc5, 0x21, 0x0b, 0xf5, 0x83, 0x23,
+	0x17, 0x07, 0xcc, 0x5f, 0x42, 0x92, 0x08, 0xa5, 0x68, 0xc1, 0x22, 0x25, 0x85, 0x4d, 0x0a, 0x61,
+	0x44, 0x30, 0x16, 0x23, 0x82, 0x71, 0x1b, 0x81, 0xee, 0xbd, 0x24, 0x36, 0x70, 0x32, 0x31, 0x06,
+	0x0c, 0x00, 0xce, 0x8d, 0x8d, 0x4a, 0x38, 0x02, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// LogAdminClient is the client API for LogAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type LogAdminClient interface {
+	// ListLoggers returns all registered loggers with their levels
+	ListLoggers(ctx context.Context, in *ListLoggersRequest, opts ...grpc.CallOption) (*ListLoggersResponse, error)
+	// GetLevel returns the level of the logger
+	GetLevel(ctx context.Context, in *GetLevelRequest, opts ...grpc.CallOption) (*GetLevelResponse, error)
+	// SetLevel sets the level of the logger and its descendants
+	SetLevel(ctx context.Context, in *SetLevelRequest, opts ...grpc.CallOption) (*SetLevelResponse, error)
+}
+
+type logAdminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewLogAdminClient(cc *grpc.ClientConn) LogAdminClient {
+	return &logAdminClient{cc}
+}
+
+func (c *logAdminClient) ListLoggers(ctx context.Context, in *ListLoggersRequest, opts ...grpc.CallOption) (*ListLoggersResponse, error) {
+	out := new(ListLoggersResponse)
+	err := c.cc.Invoke(ctx, "/logadmin.LogAdmin/ListLoggers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) GetLevel(ctx context.Context, in *GetLevelRequest, opts ...grpc.CallOption) (*GetLevelResponse, error) {
+	out := new(GetLevelResponse)
+	err := c.cc.Invoke(ctx, "/logadmin.LogAdmin/GetLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logAdminClient) SetLevel(ctx context.Context, in *SetLevelRequest, opts ...grpc.CallOption) (*SetLevelResponse, error) {
+	out := new(SetLevelResponse)
+	err := c.cc.Invoke(ctx, "/logadmin.LogAdmin/SetLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogAdminServer is the server API for LogAdmin service.
+type LogAdminServer interface {
+	// ListLoggers returns all registered loggers with their levels
+	ListLoggers(context.Context, *ListLoggersRequest) (*ListLoggersResponse, error)
+	// GetLevel returns the level of the logger
+	GetLevel(context.Context, *GetLevelRequest) (*GetLevelResponse, error)
+	// SetLevel sets the level of the logger and its descendants
+	SetLevel(context.Context, *SetLevelRequest) (*SetLevelResponse, error)
+}
+
+// UnimplementedLogAdminServer can be embedded to have forward compatible implementations.
+type UnimplementedLogAdminServer struct {
+}
+
+func (*UnimplementedLogAdminServer) ListLoggers(ctx context.Context, req *ListLoggersRequest) (*ListLoggersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLoggers not implemented")
+}
+func (*UnimplementedLogAdminServer) GetLevel(ctx context.Context, req *GetLevelRequest) (*GetLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLevel not implemented")
+}
+func (*UnimplementedLogAdminServer) SetLevel(ctx context.Context, req *SetLevelRequest) (*SetLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLevel not implemented")
+}
+
+func RegisterLogAdminServer(s *grpc.Server, srv LogAdminServer) {
+	s.RegisterService(&_LogAdmin_serviceDesc, srv)
+}
+
+func _LogAdmin_ListLoggers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLoggersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).ListLoggers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/logadmin.LogAdmin/ListLoggers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).ListLoggers(ctx, req.(*ListLoggersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_GetLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).GetLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/logadmin.LogAdmin/GetLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).GetLevel(ctx, req.(*GetLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogAdmin_SetLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogAdminServer).SetLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/logadmin.LogAdmin/SetLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogAdminServer).SetLevel(ctx, req.(*SetLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _LogAdmin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "logadmin.LogAdmin",
+	HandlerType: (*LogAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListLoggers",
+			Handler:    _LogAdmin_ListLoggers_Handler,
+		},
+		{
+			MethodName: "GetLevel",
+			Handler:    _LogAdmin_GetLevel_Handler,
+		},
+		{
+			MethodName: "SetLevel",
+			Handler:    _LogAdmin_SetLevel_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "logadmin.proto",
+}
@@ -1,6 +1,8 @@
 package logmanager
 
 import (
+	"io"
+
 	"go.ligato.io/cn-infra/v2/logging"
 	"go.ligato.io/cn-infra/v2/rpc/rest"
 	"go.ligato.io/cn-infra/v2/servicelabel"
@@ -43,3 +45,19 @@ func UseConf(conf Config) Option {
 		p.Config = &conf
 	}
 }
+
+// UseSinkErrorHandler returns Option which sets handler called when a log hook
+// fails to deliver an entry. By default, failures are reported to stderr.
+func UseSinkErrorHandler(handler SinkErrorHandler) Option {
+	return func(p *Plugin) {
+		p.sinkErrorHandler = handler
+	}
+}
+
+// UseFallbackSink returns Option which sets writer receiving entries that log hooks
+// failed to deliver. Fallback defined in hook configuration takes precedence.
+func UseFallbackSink(w io.Writer) Option {
+	return func(p *Plugin) {
+		p.fallbackSink = w
+	}
+}
@@ -16,6 +16,7 @@ package logmanager
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 
@@ -45,6 +46,11 @@ type Plugin struct {
 	Deps
 
 	*Config
+
+	// log sink failure handling
+	hooks            []*commonHook
+	sinkErrorHandler SinkErrorHandler
+	fallbackSink     io.Writer
 }
 
 // Deps groups dependencies injected into the plugin so that they are
@@ -130,7 +136,7 @@ func (p *Plugin) AfterInit() error {
 
 // Close is called at plugin cleanup phase.
 func (p *Plugin) Close() error {
-	return nil
+	return p.closeHooks()
 }
 
 // ListLoggers lists all registered loggers.
@@ -0,0 +1,115 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logrus
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+func TestBinaryFormatter(t *testing.T) {
+	RegisterTestingT(t)
+
+	logger := NewLogger("testLogger")
+	logger.SetFormatter(&BinaryFormatter{})
+	var buffer bytes.Buffer
+	logger.SetOutput(&buffer)
+
+	logger.WithFields(map[string]interface{}{
+		"str":   "value",
+		"int":   -42,
+		"uint":  uint32(42),
+		"float": 1.5,
+		"bool":  true,
+		"err":   errors.New("failure"),
+	}).Warn("first")
+	logger.Info("second")
+
+	decoder := NewBinaryDecoder(&buffer)
+
+	entry, err := decoder.Decode()
+	Expect(err).To(BeNil())
+	Expect(entry.Version).To(BeEquivalentTo(BinaryFormatVersion))
+	Expect(entry.Level).To(Equal(logrus.WarnLevel))
+	Expect(entry.Message).To(Equal("first"))
+	Expect(entry.Time.IsZero()).To(BeFalse())
+	Expect(entry.Data).To(And(
+		HaveKeyWithValue("str", "value"),
+		HaveKeyWithValue("int", int64(-42)),
+		HaveKeyWithValue("uint", uint64(42)),
+		HaveKeyWithValue("float", 1.5),
+		HaveKeyWithValue("bool", true),
+		HaveKeyWithValue("err", "failure"),
+		HaveKeyWithValue(LoggerKey, "testLogger"),
+	))
+
+	entry, err = decoder.Decode()
+	Expect(err).To(BeNil())
+	Expect(entry.Level).To(Equal(logrus.InfoLevel))
+	Expect(entry.Message).To(Equal("second"))
+
+	_, err = decoder.Decode()
+	Expect(err).To(Equal(io.EOF))
+}
+
+func TestBinaryDecodeUnsupportedVersion(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := DecodeBinaryEntry(bytes.NewReader([]byte{0, 0, 0, 2, 99, 4}))
+	Expect(err).To(HaveOccurred())
+}
+
+func TestBinaryDecodeOversizedRecord(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := DecodeBinaryEntry(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, BinaryFormatVersion, 4}))
+	Expect(err).To(MatchError(ContainSubstring("too large")))
+}
+
+func benchmarkFormatter(b *testing.B, formatter logrus.Formatter) {
+	logger := NewLogger("benchLogger")
+	logger.SetFormatter(formatter)
+	logger.SetOutput(ioutil.Discard)
+
+	var size int
+	entry := logger.WithFields(map[string]interface{}{
+		"component": "bench",
+		"attempt":   3,
+		"duration":  0.125,
+		"success":   true,
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.(*Entry).lgEntry.Message = "benchmark message with some text"
+		out, _ := formatter.Format(entry.(*Entry).lgEntry)
+		size = len(out)
+	}
+	b.ReportMetric(float64(size), "bytes/entry")
+}
+
+func BenchmarkBinaryFormatter(b *testing.B) {
+	benchmarkFormatter(b, &BinaryFormatter{})
+}
+
+func BenchmarkJSONFormatter(b *testing.B) {
+	benchmarkFormatter(b, &logrus.JSONFormatter{})
+}
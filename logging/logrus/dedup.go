@@ -0,0 +1,165 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logrus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DedupFormatter collapses identical consecutive entries (same level, message and fields) logged within
+// the window into the first entry and a summary entry with the number of suppressed entries under
+// RepeatedKey, like "message repeated N times" of syslog. The summary is written along with the next
+// entry with a different signature, or with the next identical entry after the window expired or after
+// MaxSuppressed entries were suppressed. If no such entry comes, the summary is logged on its own when
+// the window expires (see Flush). It tames retry loops logging the same failure over and over:
+//
+//	logger.SetFormatter(NewDedupFormatter(NewFormatter(), time.Second, 1000))
+type DedupFormatter struct {
+	// Formatter formats the entries
+	Formatter logrus.Formatter
+	// Window is the time since the first entry in which identical entries are suppressed
+	Window time.Duration
+	// MaxSuppressed limits number of entries suppressed in a row (0 means no limit)
+	MaxSuppressed int
+
+	mu         sync.Mutex
+	signature  string
+	first      time.Time
+	last       logrus.Entry
+	suppressed int
+	run        int // identifies the current run of identical entries
+	timer      *time.Timer
+}
+
+// NewDedupFormatter returns a formatter suppressing identical entries within the window.
+func NewDedupFormatter(formatter logrus.Formatter, window time.Duration, maxSuppressed int) *DedupFormatter {
+	return &DedupFormatter{
+		Formatter:     formatter,
+		Window:        window,
+		MaxSuppressed: maxSuppressed,
+	}
+}
+
+// Format formats the entry, or returns no data if the entry is suppressed.
+func (f *DedupFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	signature := entrySignature(entry)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if signature == f.signature && entry.Time.Sub(f.first) <= f.Window &&
+		(f.MaxSuppressed <= 0 || f.suppressed < f.MaxSuppressed) {
+		f.suppressed++
+		f.last = *entry
+		if f.suppressed == 1 && entry.Logger != nil {
+			run := f.run
+			f.timer = time.AfterFunc(f.Window, func() {
+				f.flush(run)
+			})
+		}
+		return []byte{}, nil
+	}
+
+	summary, err := f.summary()
+	if err != nil {
+		return nil, err
+	}
+	f.signature, f.first = signature, entry.Time
+	f.reset()
+	data, err := f.Formatter.Format(entry)
+	if err != nil || len(summary) == 0 {
+		return data, err
+	}
+	return append(summary, data...), nil
+}
+
+// Flush logs the summary of suppressed entries (if any) right away, using the logger of the suppressed
+// entries. It is called automatically when the window of the first suppressed entry expires.
+func (f *DedupFormatter) Flush() {
+	f.mu.Lock()
+	run := f.run
+	f.mu.Unlock()
+	f.flush(run)
+}
+
+// flush logs the summary if the run of identical entries is still the current one
+func (f *DedupFormatter) flush(run int) {
+	f.mu.Lock()
+	if run != f.run || f.suppressed == 0 || f.last.Logger == nil {
+		f.mu.Unlock()
+		return
+	}
+	entry := f.summaryEntry()
+	f.signature = ""
+	f.reset()
+	f.mu.Unlock()
+
+	// logging at panic level would panic
+	if entry.Level != logrus.PanicLevel {
+		entry.Logger.WithFields(entry.Data).Log(entry.Level, entry.Message)
+	}
+}
+
+// reset starts a new run of identical entries
+func (f *DedupFormatter) reset() {
+	f.suppressed = 0
+	f.run++
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+}
+
+// summary formats the entry reporting the suppressed entries, if any
+func (f *DedupFormatter) summary() ([]byte, error) {
+	if f.suppressed == 0 {
+		return nil, nil
+	}
+	entry := f.summaryEntry()
+	return f.Formatter.Format(&entry)
+}
+
+// summaryEntry returns the entry reporting the suppressed entries
+func (f *DedupFormatter) summaryEntry() logrus.Entry {
+	entry := f.last
+	entry.Buffer = nil
+	entry.Data = make(logrus.Fields, len(f.last.Data)+1)
+	for k, v := range f.last.Data {
+		entry.Data[k] = v
+	}
+	entry.Data[RepeatedKey] = f.suppressed
+	entry.Message = fmt.Sprintf("message repeated %d times: %s", f.suppressed, f.last.Message)
+	return entry
+}
+
+// entrySignature identifies entries with the same level, message and fields
+func entrySignature(entry *logrus.Entry) string {
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d|%s", entry.Level, entry.Message)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "|%s=%v", k, entry.Data[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logrus
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDedupFormatter(t *testing.T) {
+	RegisterTestingT(t)
+
+	formatter := NewDedupFormatter(&LogfmtFormatter{}, time.Second, 3)
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	format := func(offset time.Duration, msg string, fields logrus.Fields) []string {
+		data, err := formatter.Format(&logrus.Entry{
+			Time:    start.Add(offset),
+			Level:   logrus.WarnLevel,
+			Message: msg,
+			Data:    fields,
+		})
+		Expect(err).To(BeNil())
+		if len(data) == 0 {
+			return nil
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+	failure := logrus.Fields{"attempt": "retry"}
+
+	Expect(format(0, "connect failed", failure)).To(HaveLen(1))
+	Expect(format(100*time.Millisecond, "connect failed", failure)).To(BeEmpty())
+	Expect(format(200*time.Millisecond, "connect failed", failure)).To(BeEmpty())
+
+	// different fields change the signature, suppressed entries are summarized
+	lines := format(300*time.Millisecond, "connect failed", logrus.Fields{"attempt": "last"})
+	Expect(lines).To(HaveLen(2))
+	Expect(lines[0]).To(Equal(`time=2020-01-01T10:00:00.200000Z level=warning ` +
+		`msg="message repeated 2 times: connect failed" attempt=retry repeated=2`))
+	Expect(lines[1]).To(ContainSubstring("attempt=last"))
+
+	// the window expired
+	Expect(format(1200*time.Millisecond, "connect failed", logrus.Fields{"attempt": "last"})).To(BeEmpty())
+	lines = format(1400*time.Millisecond, "connect failed", logrus.Fields{"attempt": "last"})
+	Expect(lines).To(HaveLen(2))
+	Expect(lines[0]).To(ContainSubstring("repeated=1"))
+
+	// the maximum number of suppressed entries was reached
+	for i := 0; i < 3; i++ {
+		Expect(format(1500*time.Millisecond, "connect failed", logrus.Fields{"attempt": "last"})).To(BeEmpty())
+	}
+	lines = format(1600*time.Millisecond, "connect failed", logrus.Fields{"attempt": "last"})
+	Expect(lines).To(HaveLen(2))
+	Expect(lines[0]).To(ContainSubstring("repeated=3"))
+}
+
+func TestDedupLogger(t *testing.T) {
+	RegisterTestingT(t)
+
+	logger := NewLogger("dedup")
+	logger.SetFormatter(NewDedupFormatter(&LogfmtFormatter{}, time.Minute, 0))
+	var buf strings.Builder
+	logger.SetOutput(&buf)
+
+	for i := 0; i < 1000; i++ {
+		logger.Warn("retrying")
+	}
+	logger.Info("done")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	Expect(lines).To(HaveLen(3))
+	Expect(lines[1]).To(ContainSubstring("repeated=999"))
+	Expect(lines[2]).To(ContainSubstring("msg=done"))
+}
+
+func TestDedupFlush(t *testing.T) {
+	RegisterTestingT(t)
+
+	logger := NewLogger("dedup-flush")
+	logger.SetFormatter(NewDedupFormatter(&LogfmtFormatter{}, 100*time.Millisecond, 0))
+	buf := &syncBuffer{}
+	logger.SetOutput(buf)
+
+	for i := 0; i < 10; i++ {
+		logger.Warn("retrying")
+	}
+	// the summary is written when the window expires, without waiting for another entry
+	Eventually(buf.String, time.Second).Should(ContainSubstring("repeated=9"))
+	Expect(strings.Split(strings.TrimSpace(buf.String()), "\n")).To(HaveLen(2))
+
+	// the next identical entry starts a new run
+	logger.Warn("retrying")
+	logger.Warn("retrying")
+	formatter := logger.Logger.Formatter.(*DedupFormatter)
+	formatter.Flush()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	Expect(lines).To(HaveLen(4))
+	Expect(lines[3]).To(ContainSubstring("repeated=1"))
+	Consistently(buf.String, 300*time.Millisecond).Should(Equal(strings.Join(lines, "\n") + "\n"))
+}
+
+// syncBuffer is a buffer safe for concurrent use by the logger and the test
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -35,6 +35,13 @@ const (
 	LoggerKey   = "logger"
 	FunctionKey = "func"
 	LocationKey = "loc"
+	// DroppedFieldsKey holds number of fields dropped due to Formatter.MaxFields
+	DroppedFieldsKey = "fields_dropped"
+	// HostKey and PIDKey identify origin of the entry, see LogRegistry.SetOriginFields
+	HostKey = "host"
+	PIDKey  = "pid"
+	// RepeatedKey holds number of entries suppressed by DedupFormatter
+	RepeatedKey = "repeated"
 )
 
 func sortKeys(keys []string) {
@@ -51,11 +58,19 @@ func sortKeys(keys []string) {
 	})
 }
 
+// Formatter is the default formatter for loggers. Fields of entries are always written
+// in deterministic order: sorted by key, with caller fields (func, loc) at the end.
 type Formatter struct {
 	Function bool
 	Location bool
 	FullPath bool
 
+	// MaxFields limits number of fields written for single entry (0 means no limit).
+	// If exceeded, only the first MaxFields fields (in sorted order) are kept and
+	// the number of dropped fields is written under DroppedFieldsKey. The logger
+	// name is not counted and never dropped.
+	MaxFields int
+
 	Formatter logrus.Formatter
 }
 
@@ -70,6 +85,9 @@ func NewFormatter() *Formatter {
 }
 
 func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if f.MaxFields > 0 && len(entry.Data) > f.MaxFields {
+		entry.Data = limitFields(entry.Data, f.MaxFields)
+	}
 	if f.Function || f.Location {
 		if caller := getCaller(); caller != nil {
 			data := logrus.Fields{}
@@ -92,3 +110,26 @@ func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
 	}
 	return f.Formatter.Format(entry)
 }
+
+// limitFields returns copy of data with only first max fields in sorted order (besides the logger name)
+func limitFields(data logrus.Fields, max int) logrus.Fields {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		if k != LoggerKey {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) <= max {
+		return data
+	}
+	sort.Strings(keys)
+	limited := make(logrus.Fields, max+2)
+	for _, k := range keys[:max] {
+		limited[k] = data[k]
+	}
+	if logger, ok := data[LoggerKey]; ok {
+		limited[LoggerKey] = logger
+	}
+	limited[DroppedFieldsKey] = len(keys) - max
+	return limited
+}
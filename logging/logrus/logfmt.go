@@ -0,0 +1,129 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logrus
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// FormatLogfmt is the name under which LogfmtFormatter is registered for logging.Config.Format.
+const FormatLogfmt = "logfmt"
+
+func init() {
+	logging.RegisterFormatter(FormatLogfmt, &LogfmtFormatter{})
+}
+
+// LogfmtFormatter renders log entries as single line of logfmt key=value pairs:
+//
+//	time=2020-01-01T10:00:00.000000Z level=info msg="some message" key=value
+//
+// Time, level and message are always first (under keys time, level and msg), followed by fields
+// sorted by key. Values containing spaces, equal signs, quotes or control characters are quoted.
+type LogfmtFormatter struct {
+	// TimestampFormat defaults to RFC3339 with microseconds
+	TimestampFormat string
+}
+
+// DefaultLogfmtTimestampFormat is the timestamp format used by LogfmtFormatter by default.
+const DefaultLogfmtTimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// Format renders a single log entry.
+func (f *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	timestampFormat := f.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = DefaultLogfmtTimestampFormat
+	}
+
+	appendLogfmtPair(b, "time", entry.Time.Format(timestampFormat))
+	appendLogfmtPair(b, "level", entry.Level.String())
+	appendLogfmtPair(b, "msg", entry.Message)
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		appendLogfmtPair(b, k, logfmtValue(entry.Data[k]))
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func logfmtValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case error:
+		return val.Error()
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return val.String()
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func appendLogfmtPair(b *bytes.Buffer, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteByte('=')
+	if logfmtNeedsQuoting(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+// logfmtKey replaces characters not allowed in keys
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func logfmtNeedsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
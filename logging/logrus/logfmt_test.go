@@ -0,0 +1,67 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logrus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogfmtFormatter(t *testing.T) {
+	RegisterTestingT(t)
+
+	formatter := &LogfmtFormatter{}
+	entry := &logrus.Entry{
+		Time:    time.Date(2020, 1, 2, 3, 4, 5, 600000000, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "hello world",
+		Data: logrus.Fields{
+			"simple":  "value",
+			"spaces":  "a b",
+			"equals":  "a=b",
+			"quotes":  `say "hi"`,
+			"empty":   "",
+			"num":     42,
+			"err":     errors.New("failed"),
+			"bad key": true,
+		},
+	}
+	out, err := formatter.Format(entry)
+	Expect(err).To(BeNil())
+	Expect(string(out)).To(Equal(`time=2020-01-02T03:04:05.600000Z level=warning msg="hello world" ` +
+		`bad_key=true empty="" equals="a=b" err=failed num=42 quotes="say \"hi\"" simple=value spaces="a b"` + "\n"))
+}
+
+func TestLogfmtFormatterLogger(t *testing.T) {
+	RegisterTestingT(t)
+
+	logger := NewLogger("testLogger")
+	logger.SetFormatter(&LogfmtFormatter{})
+	var buffer bytes.Buffer
+	logger.SetOutput(&buffer)
+
+	logger.WithField("key", "value").Info("first")
+	logger.Info("second")
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	Expect(lines).To(HaveLen(2))
+	Expect(string(lines[0])).To(HaveSuffix("level=info msg=first key=value logger=testLogger"))
+	Expect(string(lines[1])).To(HaveSuffix("level=info msg=second logger=testLogger"))
+}
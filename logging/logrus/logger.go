@@ -20,6 +20,7 @@ import (
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 
@@ -58,7 +59,7 @@ type Logger struct {
 	Logger *logrus.Logger
 
 	name         string
-	verbosity    int
+	verbosity    int32
 	staticFields sync.Map
 }
 
@@ -97,6 +98,17 @@ func (logger *Logger) SetStaticFields(fields map[string]interface{}) {
 	}
 }
 
+// replaceStaticFields replaces all static fields of the logger with the given ones
+func (logger *Logger) replaceStaticFields(fields map[string]interface{}) {
+	logger.staticFields.Range(func(k, v interface{}) bool {
+		if _, ok := fields[k.(string)]; !ok {
+			logger.staticFields.Delete(k)
+		}
+		return true
+	})
+	logger.SetStaticFields(fields)
+}
+
 // GetStaticFields returns currently set map of static fields - key-value pairs
 // that are automatically added into log entry
 func (logger *Logger) GetStaticFields() map[string]interface{} {
@@ -125,12 +137,26 @@ func (logger *Logger) GetStaticFields() map[string]interface{} {
 // correspond with the Logger plugin log levels. See the documentation of the
 // given library to learn about supported verbosity levels.
 func (logger *Logger) SetVerbosity(v int) {
-	logger.verbosity = v
+	atomic.StoreInt32(&logger.verbosity, int32(v))
+}
+
+// GetVerbosity returns the verbosity threshold of the logger.
+func (logger *Logger) GetVerbosity() int {
+	return int(atomic.LoadInt32(&logger.verbosity))
 }
 
 // V reports whether verbosity level is at least at the requested level
 func (logger *Logger) V(l int) bool {
-	return l <= logger.verbosity
+	return l <= logger.GetVerbosity()
+}
+
+// Verbose returns the logger if its verbosity is at least n, otherwise the returned
+// logger discards all entries.
+func (logger *Logger) Verbose(n int) logging.LogWithLevel {
+	if logger.V(n) {
+		return logger
+	}
+	return logging.DiscardLog
 }
 
 func (logger *Logger) SetLevel(lvl logging.LogLevel) {
@@ -141,6 +167,11 @@ func (logger *Logger) GetLevel() logging.LogLevel {
 	return logging.LogLevel(logger.Logger.GetLevel())
 }
 
+// IsLevelEnabled returns true if entries with given level are logged.
+func (logger *Logger) IsLevelEnabled(lvl logging.LogLevel) bool {
+	return logger.Logger.IsLevelEnabled(logrus.Level(lvl))
+}
+
 func (logger *Logger) AddHook(hook logrus.Hook) {
 	logger.Logger.AddHook(hook)
 }
@@ -149,6 +180,10 @@ func (logger *Logger) SetOutput(out io.Writer) {
 	logger.Logger.SetOutput(out)
 }
 
+// SetFormatter swaps the formatter used by the logger. The swap is done under
+// the same lock that guards writing of log entries, so it is safe to call it
+// at runtime while other goroutines are logging. Each entry is formatted
+// entirely by either the old or the new formatter.
 func (logger *Logger) SetFormatter(formatter logrus.Formatter) {
 	logger.Logger.SetFormatter(formatter)
 }
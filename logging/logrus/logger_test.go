@@ -17,6 +17,8 @@ package logrus
 import (
 	"bytes"
 	"encoding/json"
+	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -315,3 +317,169 @@ func TestLogInterface(t *testing.T) {
 	e := logger.WithField("another", "value")
 	logFn(e.(*Entry).logger)
 }
+
+func TestSetFormatterWhileLogging(t *testing.T) {
+	RegisterTestingT(t)
+
+	logger := NewLogger("testLogger")
+	var buffer bytes.Buffer
+	logger.SetOutput(&buffer)
+
+	jsonFormatter := &lg.JSONFormatter{}
+	textFormatter := &lg.TextFormatter{DisableColors: true}
+	logger.SetFormatter(textFormatter)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.WithField("key", "value").Info("concurrent message")
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			if j%2 == 0 {
+				logger.SetFormatter(jsonFormatter)
+			} else {
+				logger.SetFormatter(textFormatter)
+			}
+		}
+	}()
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	Expect(lines).To(HaveLen(1000))
+	for _, line := range lines {
+		if strings.HasPrefix(line, "{") {
+			var fields map[string]interface{}
+			Expect(json.Unmarshal([]byte(line), &fields)).To(Succeed(), line)
+			Expect(fields).To(HaveKeyWithValue("msg", "concurrent message"))
+		} else {
+			Expect(line).To(HavePrefix("time="))
+			Expect(line).To(ContainSubstring(`msg="concurrent message"`))
+		}
+	}
+}
+
+func TestFormatterFieldOrder(t *testing.T) {
+	RegisterTestingT(t)
+
+	logger := NewLogger("testLogger")
+	logger.SetFormatter(NewFormatter())
+	var buffer bytes.Buffer
+	logger.SetOutput(&buffer)
+
+	fields := map[string]interface{}{"c": 3, "a": 1, "e": 5, "b": 2, "d": 4}
+	for i := 0; i < 20; i++ {
+		logger.WithFields(fields).Info("test")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	Expect(lines).To(HaveLen(20))
+	for _, line := range lines {
+		Expect(line).To(ContainSubstring("a=1 b=2 c=3 d=4 e=5"))
+	}
+}
+
+func TestFormatterMaxFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	formatter := NewFormatter()
+	formatter.MaxFields = 2
+	logger := NewLogger("testLogger")
+	logger.SetFormatter(formatter)
+	var buffer bytes.Buffer
+	logger.SetOutput(&buffer)
+
+	logger.WithFields(map[string]interface{}{"c": 3, "a": 1, "b": 2, "d": 4}).Info("test")
+
+	out := buffer.String()
+	Expect(out).To(ContainSubstring("a=1 b=2 " + DroppedFieldsKey + "=2"))
+	Expect(out).To(ContainSubstring("logger=testLogger"))
+	Expect(out).ToNot(ContainSubstring("c=3"))
+	Expect(out).ToNot(ContainSubstring("d=4"))
+}
+
+func TestDisabledLevelDoesNotAllocate(t *testing.T) {
+	RegisterTestingT(t)
+
+	logger := NewLogger("testLogger")
+	logger.SetLevel(logging.InfoLevel)
+	var log logging.Logger = logger
+
+	Expect(logging.IsLevelEnabled(log, logging.DebugLevel)).To(BeFalse())
+	Expect(logging.IsLevelEnabled(log, logging.InfoLevel)).To(BeTrue())
+	// loggers without LevelChecker are checked against their level
+	plain := struct{ logging.Logger }{log}
+	Expect(logging.IsLevelEnabled(plain, logging.DebugLevel)).To(BeFalse())
+	Expect(logging.IsLevelEnabled(plain, logging.InfoLevel)).To(BeTrue())
+
+	// boxing of a non-constant value above 255 into interface{} allocates
+	value := 123456 + len(os.Args)
+
+	// without the guard, the caller allocates the arguments even though the level is disabled
+	allocs := testing.AllocsPerRun(100, func() {
+		log.Debugf("value %d", value)
+	})
+	Expect(allocs).To(BeNumerically(">", 0))
+
+	allocs = testing.AllocsPerRun(100, func() {
+		if logging.IsLevelEnabled(log, logging.DebugLevel) {
+			log.Debugf("value %d", value)
+		}
+	})
+	Expect(allocs).To(BeZero())
+}
+
+func TestStartupBanner(t *testing.T) {
+	logAndAssertJSON(t, func(log *Logger) {
+		info := logging.StartupInfo{Name: "agent", Version: "v1.2.3", Commit: "abcdef"}
+		logging.LogStartupBanner(log, info)
+		// logged only once
+		logging.LogStartupBanner(log, info)
+	}, func(fields map[string]interface{}) {
+		Expect(fields["msg"]).To(Equal(logging.StartupMessage))
+		Expect(fields["service"]).To(Equal("agent"))
+		Expect(fields["version"]).To(Equal("v1.2.3"))
+		Expect(fields["commit"]).To(Equal("abcdef"))
+		Expect(fields["go-version"]).To(Equal(runtime.Version()))
+		Expect(fields["pid"]).To(BeEquivalentTo(os.Getpid()))
+		Expect(fields).To(HaveKey("host"))
+		Expect(fields).To(HaveKey("args"))
+		Expect(fields).NotTo(HaveKey("build-date"))
+	})
+}
+
+func BenchmarkDisabledDebug(b *testing.B) {
+	logger := NewLogger("testLogger")
+	logger.SetLevel(logging.InfoLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Debug("value", i+256)
+	}
+}
+
+func BenchmarkDisabledDebugInterface(b *testing.B) {
+	var log logging.Logger = NewLogger("testLogger")
+	log.SetLevel(logging.InfoLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		log.Debugf("value %d", i+256)
+	}
+}
+
+func BenchmarkDisabledDebugGuarded(b *testing.B) {
+	var log logging.Logger = NewLogger("testLogger")
+	log.SetLevel(logging.InfoLevel)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if logging.IsLevelEnabled(log, logging.DebugLevel) {
+			log.Debugf("value %d", i+256)
+		}
+	}
+}
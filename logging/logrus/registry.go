@@ -16,7 +16,10 @@ package logrus
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/sirupsen/logrus"
@@ -40,6 +43,8 @@ func NewLogRegistry() *LogRegistry {
 	registry := &LogRegistry{
 		loggers:      new(sync.Map),
 		logLevels:    make(map[string]logging.LogLevel),
+		verbosities:  make(map[string]int),
+		fields:       make(map[string]map[string]interface{}),
 		defaultLevel: initialLogLvl,
 	}
 	registry.putLoggerToMapping(defaultLogger)
@@ -48,10 +53,20 @@ func NewLogRegistry() *LogRegistry {
 
 // LogRegistry contains logger map and rwlock guarding access to it
 type LogRegistry struct {
-	loggers      *sync.Map
+	loggers *sync.Map
+
+	// mu guards settings applied to loggers, which may be modified concurrently (e.g. by remote callers)
+	mu           sync.Mutex
 	logLevels    map[string]logging.LogLevel
 	defaultLevel logging.LogLevel
+	verbosities  map[string]int
+	defaultV     int
 	hooks        []logrus.Hook
+	formatter    logrus.Formatter
+	output       io.Writer
+	applied      io.Closer // output opened by logging.Apply
+	originFields map[string]interface{}
+	fields       map[string]map[string]interface{}
 }
 
 var validLoggerName = regexp.MustCompile(`^[a-zA-Z0-9.-]+$`).MatchString
@@ -66,6 +81,8 @@ func checkLoggerName(name string) error {
 // NewLogger creates new named Logger instance. Name can be subsequently used to
 // refer the logger in registry.
 func (lr *LogRegistry) NewLogger(name string) logging.Logger {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
 	if existingLogger := lr.getLoggerFromMapping(name); existingLogger != nil {
 		panic(fmt.Errorf("logger with name '%s' already exists", name))
 	}
@@ -74,10 +91,20 @@ func (lr *LogRegistry) NewLogger(name string) logging.Logger {
 	}
 
 	logger := NewLogger(name)
-	if lvl, ok := lr.logLevels[name]; ok {
-		logger.SetLevel(lvl)
+	logger.SetLevel(lr.effectiveLevel(name))
+	if v, ok := lr.verbosities[name]; ok {
+		logger.SetVerbosity(v)
 	} else {
-		logger.SetLevel(lr.defaultLevel)
+		logger.SetVerbosity(lr.defaultV)
+	}
+	if lr.formatter != nil {
+		logger.SetFormatter(lr.formatter)
+	}
+	if lr.output != nil {
+		logger.SetOutput(lr.output)
+	}
+	if fields := lr.staticFields(name); len(fields) > 0 {
+		logger.SetStaticFields(fields)
 	}
 	lr.putLoggerToMapping(logger)
 
@@ -114,21 +141,113 @@ func (lr *LogRegistry) ListLoggers() map[string]string {
 	return list
 }
 
-// SetLevel modifies log level of selected logger in the registry
+// SetLevel modifies log level of selected logger in the registry. Loggers are organized in a hierarchy
+// by dotted names, the level applies also to descendants of the logger (e.g. "app.grpc" for "app"),
+// unless they have their own level set.
 func (lr *LogRegistry) SetLevel(logger, level string) error {
 	lvl, err := logging.ParseLogLevel(level)
 	if err != nil {
 		return err
 	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
 	if logger == "default" {
 		lr.defaultLevel = lvl
 		return nil
 	}
 	lr.logLevels[logger] = lvl
-	logVal := lr.getLoggerFromMapping(logger)
-	if logVal != nil {
-		defaultLogger.Tracef("setting logger level: %v -> %v", logVal.name, lvl.String())
-		logVal.SetLevel(lvl)
+	lr.loggers.Range(func(k, v interface{}) bool {
+		if logVal, ok := v.(*Logger); ok && isDescendant(logVal.name, logger) {
+			effective := lr.effectiveLevel(logVal.name)
+			defaultLogger.Tracef("setting logger level: %v -> %v", logVal.name, effective.String())
+			logVal.SetLevel(effective)
+		}
+		return true
+	})
+	return nil
+}
+
+// SetFields sets fields added to all entries of the logger and its descendants in the hierarchy of
+// dotted names (e.g. "app.grpc" for "app"). Fields set for a descendant override fields of its ancestors.
+func (lr *LogRegistry) SetFields(logger string, fields map[string]interface{}) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.fields[logger] = fields
+	lr.loggers.Range(func(k, v interface{}) bool {
+		if logVal, ok := v.(*Logger); ok && isDescendant(logVal.name, logger) {
+			logVal.replaceStaticFields(lr.staticFields(logVal.name))
+		}
+		return true
+	})
+}
+
+// effectiveLevel returns the level set for the logger or its closest ancestor, or the default level.
+// It must be called with the lock held.
+func (lr *LogRegistry) effectiveLevel(name string) logging.LogLevel {
+	for ; name != ""; name = parentLogger(name) {
+		if lvl, ok := lr.logLevels[name]; ok {
+			return lvl
+		}
+	}
+	return lr.defaultLevel
+}
+
+// effectiveFields returns fields set for the logger and its ancestors, the closer ones taking precedence.
+// It must be called with the lock held.
+func (lr *LogRegistry) effectiveFields(name string) map[string]interface{} {
+	var names []string
+	for ; name != ""; name = parentLogger(name) {
+		names = append(names, name)
+	}
+	fields := make(map[string]interface{})
+	for i := len(names) - 1; i >= 0; i-- {
+		for k, v := range lr.fields[names[i]] {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// staticFields returns origin fields and effective fields of the logger, which make up all static fields
+// of the logger. It must be called with the lock held.
+func (lr *LogRegistry) staticFields(name string) map[string]interface{} {
+	fields := lr.effectiveFields(name)
+	for k, v := range lr.originFields {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// parentLogger returns name of the parent logger in the hierarchy of dotted names, or empty string
+func parentLogger(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// isDescendant returns true if the logger is the ancestor itself or its descendant
+func isDescendant(name, ancestor string) bool {
+	return name == ancestor || strings.HasPrefix(name, ancestor+".")
+}
+
+// SetVerbosity modifies verbosity threshold (see Logger.V) of the logger. The "default" logger
+// name sets the verbosity of loggers created later without explicit verbosity.
+func (lr *LogRegistry) SetVerbosity(logger string, v int) error {
+	if v < 0 {
+		return fmt.Errorf("invalid verbosity %d", v)
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	if logger == "default" {
+		lr.defaultV = v
+		return nil
+	}
+	lr.verbosities[logger] = v
+	if logVal := lr.getLoggerFromMapping(logger); logVal != nil {
+		logVal.SetVerbosity(v)
 	}
 	return nil
 }
@@ -197,6 +316,8 @@ func (lr *LogRegistry) getLoggerFromMapping(logger string) *Logger {
 // to be applies for new loggers.
 func (lr *LogRegistry) AddHook(hook logrus.Hook) {
 	defaultLogger.Tracef("adding hook %q to log registry", hook)
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
 	lr.hooks = append(lr.hooks, hook)
 	for loggerName := range lr.ListLoggers() {
 		logger, found := lr.lookupLogger(loggerName)
@@ -206,6 +327,79 @@ func (lr *LogRegistry) AddHook(hook logrus.Hook) {
 	}
 }
 
+// SetFormatter sets the formatter for existing loggers and loggers created later.
+func (lr *LogRegistry) SetFormatter(formatter logrus.Formatter) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.formatter = formatter
+	lr.loggers.Range(func(k, v interface{}) bool {
+		if logger, ok := v.(*Logger); ok {
+			logger.SetFormatter(formatter)
+		}
+		return true
+	})
+}
+
+// SetOutput sets the output for existing loggers and loggers created later.
+func (lr *LogRegistry) SetOutput(out io.Writer) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.output = out
+	lr.loggers.Range(func(k, v interface{}) bool {
+		if logger, ok := v.(*Logger); ok {
+			logger.SetOutput(out)
+		}
+		return true
+	})
+}
+
+// SwapAppliedOutput stores the output opened by logging.Apply and returns the previous one,
+// which is no longer used by the loggers of this registry.
+func (lr *LogRegistry) SwapAppliedOutput(out io.Closer) io.Closer {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	prev := lr.applied
+	lr.applied = out
+	return prev
+}
+
+// SetOriginFields adds the host and pid fields to entries of existing loggers and loggers
+// created later. The host defaults to the host name, a different value (e.g. pod name) can be
+// given instead. The values are resolved once, so logging does not pay for them.
+func (lr *LogRegistry) SetOriginFields(host string) {
+	if host == "" {
+		host = Hostname()
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.originFields = map[string]interface{}{
+		HostKey: host,
+		PIDKey:  os.Getpid(),
+	}
+	lr.loggers.Range(func(k, v interface{}) bool {
+		if logger, ok := v.(*Logger); ok {
+			logger.replaceStaticFields(lr.staticFields(logger.name))
+		}
+		return true
+	})
+}
+
+var (
+	hostnameOnce sync.Once
+	hostname     string
+)
+
+// Hostname returns the host name, which is resolved only once.
+func Hostname() string {
+	hostnameOnce.Do(func() {
+		var err error
+		if hostname, err = os.Hostname(); err != nil {
+			hostname = "unknown"
+		}
+	})
+	return hostname
+}
+
 func (lr *LogRegistry) lookupLogger(name string) (*Logger, bool) {
 	loggerInt, found := lr.loggers.Load(name)
 	if !found {
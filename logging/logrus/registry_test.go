@@ -15,9 +15,20 @@
 package logrus
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"go.ligato.io/cn-infra/v2/logging"
 )
 
 func TestListLoggers(t *testing.T) {
@@ -134,3 +145,229 @@ func TestClearRegistry(t *testing.T) {
 	_, found = logRegistry.Lookup(globalName)
 	Expect(found).To(BeTrue())
 }
+
+func TestApplyConfig(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "logging")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	logRegistry := NewLogRegistry()
+	existing := logRegistry.NewLogger("existing")
+	Expect(logRegistry.NewLogger("other")).NotTo(BeNil())
+
+	logFile := filepath.Join(dir, "app.log")
+	cfg := logging.Config{
+		DefaultLevel: "warn",
+		Loggers:      map[string]string{"existing": "debug"},
+		Format:       logging.FormatJSON,
+		Output:       logFile,
+	}
+	Expect(logging.Apply(cfg, logRegistry)).To(Succeed())
+
+	Expect(logRegistry.GetLevel("existing")).To(Equal("debug"))
+	Expect(logRegistry.GetLevel("other")).To(Equal("warn"))
+	Expect(logRegistry.NewLogger("later").GetLevel()).To(Equal(logging.WarnLevel))
+
+	existing.Debug("applied")
+	data, err := ioutil.ReadFile(logFile)
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(ContainSubstring(`"msg":"applied"`))
+
+	// reapplying changed config updates live loggers
+	cfg.Loggers = nil
+	Expect(logging.Apply(cfg, logRegistry)).To(Succeed())
+	Expect(logRegistry.GetLevel("existing")).To(Equal("warn"))
+
+	Expect(logging.Apply(logging.Config{Format: "xml"}, logRegistry)).NotTo(Succeed())
+}
+
+// isOpen reports whether the file is open by this process
+func isOpen(path string) bool {
+	fds, _ := ioutil.ReadDir("/proc/self/fd")
+	for _, fd := range fds {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil && target == path {
+			return true
+		}
+	}
+	return false
+}
+
+// failingRegistry fails to set log levels
+type failingRegistry struct {
+	*LogRegistry
+}
+
+func (failingRegistry) SetLevel(logger, level string) error {
+	return errors.New("set level failed")
+}
+
+func TestApplyOutputPerRegistry(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "log-output")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		t.Skip("open files cannot be listed")
+	}
+
+	first, second := NewLogRegistry(), NewLogRegistry()
+	firstFile, secondFile := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	Expect(logging.Apply(logging.Config{Output: firstFile}, first)).To(Succeed())
+	Expect(logging.Apply(logging.Config{Output: secondFile}, second)).To(Succeed())
+	// applying config to the second registry must not close output of the first one
+	Expect(isOpen(firstFile)).To(BeTrue())
+	Expect(isOpen(secondFile)).To(BeTrue())
+
+	// replaced output is closed, stdout is never closed
+	Expect(logging.Apply(logging.Config{Output: "stdout"}, first)).To(Succeed())
+	Expect(isOpen(firstFile)).To(BeFalse())
+	Expect(logging.Apply(logging.Config{Output: secondFile}, first)).To(Succeed())
+	_, err = os.Stdout.Stat()
+	Expect(err).To(BeNil())
+
+	// output opened by failed Apply is closed
+	failedFile := filepath.Join(dir, "failed.log")
+	cfg := logging.Config{Output: failedFile, DefaultLevel: "debug"}
+	Expect(logging.Apply(cfg, failingRegistry{first})).NotTo(Succeed())
+	Expect(isOpen(failedFile)).To(BeFalse())
+	Expect(isOpen(secondFile)).To(BeTrue())
+}
+
+func TestOriginFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	logRegistry := NewLogRegistry()
+	existing := logRegistry.NewLogger("existing")
+	Expect(logging.Apply(logging.Config{OriginFields: true, Host: "pod-1"}, logRegistry)).To(Succeed())
+
+	later := logRegistry.NewLogger("later")
+	for _, logger := range []logging.Logger{existing, later} {
+		fields := logger.(*Logger).GetStaticFields()
+		Expect(fields).To(HaveKeyWithValue(HostKey, "pod-1"))
+		Expect(fields).To(HaveKeyWithValue(PIDKey, os.Getpid()))
+	}
+
+	logRegistry.SetOriginFields("")
+	Expect(later.(*Logger).GetStaticFields()).To(HaveKeyWithValue(HostKey, Hostname()))
+}
+
+type upperFormatter struct{}
+
+func (upperFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return []byte(strings.ToUpper(entry.Message) + "\n"), nil
+}
+
+func TestRegisteredFormatter(t *testing.T) {
+	RegisterTestingT(t)
+
+	logging.RegisterFormatter("upper", upperFormatter{})
+	Expect(logging.RegisteredFormatters()).To(Equal([]string{
+		logging.FormatJSON, FormatLogfmt, logging.FormatText, "upper",
+	}))
+
+	logRegistry := NewLogRegistry()
+	logger := logRegistry.NewLogger("formatted")
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	Expect(logging.Apply(logging.Config{Format: "upper"}, logRegistry)).To(Succeed())
+	logger.Info("custom format")
+	Expect(buf.String()).To(Equal("CUSTOM FORMAT\n"))
+
+	buf.Reset()
+	Expect(logging.Apply(logging.Config{Format: FormatLogfmt}, logRegistry)).To(Succeed())
+	logger.Info("logfmt")
+	Expect(buf.String()).To(ContainSubstring(`level=info msg=logfmt`))
+}
+
+func TestHierarchy(t *testing.T) {
+	RegisterTestingT(t)
+
+	logRegistry := NewLogRegistry()
+	app := logRegistry.NewLogger("app")
+	server := logRegistry.NewLogger("app.grpc.server")
+	other := logRegistry.NewLogger("application")
+
+	Expect(logRegistry.SetLevel("app", "debug")).To(Succeed())
+	Expect(app.GetLevel()).To(Equal(logging.DebugLevel))
+	Expect(server.GetLevel()).To(Equal(logging.DebugLevel))
+	Expect(other.GetLevel()).To(Equal(logging.InfoLevel))
+
+	// closer level overrides the ancestor
+	Expect(logRegistry.SetLevel("app.grpc", "error")).To(Succeed())
+	Expect(logRegistry.SetLevel("app", "warn")).To(Succeed())
+	Expect(app.GetLevel()).To(Equal(logging.WarnLevel))
+	Expect(server.GetLevel()).To(Equal(logging.ErrorLevel))
+	Expect(logRegistry.NewLogger("app.grpc.client").GetLevel()).To(Equal(logging.ErrorLevel))
+	Expect(logRegistry.NewLogger("app.rest").GetLevel()).To(Equal(logging.WarnLevel))
+	Expect(logRegistry.ListLoggers()).To(HaveKeyWithValue("app.grpc.client", "error"))
+
+	logRegistry.SetFields("app", map[string]interface{}{"service": "app", "component": "core"})
+	logRegistry.SetFields("app.grpc", map[string]interface{}{"component": "grpc"})
+	Expect(app.(*Logger).GetStaticFields()).To(Equal(map[string]interface{}{"service": "app", "component": "core"}))
+	Expect(server.(*Logger).GetStaticFields()).To(Equal(map[string]interface{}{"service": "app", "component": "grpc"}))
+	Expect(logRegistry.NewLogger("app.grpc.stream").(*Logger).GetStaticFields()).To(
+		HaveKeyWithValue("component", "grpc"))
+	Expect(other.(*Logger).GetStaticFields()).To(BeEmpty())
+
+	// changed and removed fields of the parent are reflected by existing descendants
+	logRegistry.SetFields("app", map[string]interface{}{"service": "app-v2"})
+	Expect(server.(*Logger).GetStaticFields()).To(Equal(map[string]interface{}{"service": "app-v2", "component": "grpc"}))
+	Expect(app.(*Logger).GetStaticFields()).To(Equal(map[string]interface{}{"service": "app-v2"}))
+	logRegistry.SetFields("app", nil)
+	Expect(server.(*Logger).GetStaticFields()).To(Equal(map[string]interface{}{"component": "grpc"}))
+	Expect(server.(*Logger).GetStaticFields()).To(Equal(
+		logRegistry.NewLogger("app.grpc.later").(*Logger).GetStaticFields()))
+}
+
+func TestVerbosity(t *testing.T) {
+	RegisterTestingT(t)
+
+	var logRegistry logging.VerbosityRegistry = NewLogRegistry()
+	Expect(logRegistry.SetVerbosity("default", 1)).To(Succeed())
+	logger, ok := logRegistry.(logging.Registry).NewLogger("verbose").(logging.VerboseLogger)
+	Expect(ok).To(BeTrue())
+	Expect(logger.GetVerbosity()).To(Equal(1))
+
+	var buffer bytes.Buffer
+	logger.(logging.Logger).SetOutput(&buffer)
+	logger.Verbose(1).Info("shown")
+	logger.Verbose(2).Info("hidden")
+	Expect(buffer.String()).To(ContainSubstring("shown"))
+	Expect(buffer.String()).NotTo(ContainSubstring("hidden"))
+
+	Expect(logRegistry.SetVerbosity("verbose", 2)).To(Succeed())
+	logger.Verbose(2).WithField("key", "value").Info("now shown")
+	Expect(buffer.String()).To(ContainSubstring("now shown"))
+	Expect(logger.Verbose(3)).To(Equal(logging.DiscardLog))
+	Expect(logger.(*Logger).V(2)).To(BeTrue())
+	Expect(logger.(*Logger).V(3)).To(BeFalse())
+	Expect(func() { logging.DiscardLog.Panic("discarded") }).To(Panic())
+
+	Expect(logRegistry.SetVerbosity("verbose", -1)).NotTo(Succeed())
+}
+
+func TestRegistryConcurrentUpdates(t *testing.T) {
+	RegisterTestingT(t)
+
+	logRegistry := NewLogRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("app.worker-%d", i)
+			logRegistry.NewLogger(name)
+			Expect(logRegistry.SetLevel("app", "debug")).To(Succeed())
+			Expect(logRegistry.SetLevel(name, "warn")).To(Succeed())
+			Expect(logRegistry.SetVerbosity(name, i)).To(Succeed())
+			logRegistry.SetFields("app", map[string]interface{}{"worker": i})
+			logRegistry.ListLoggers()
+		}(i)
+	}
+	wg.Wait()
+	Expect(logRegistry.GetLevel("app.worker-0")).To(Equal("warn"))
+}
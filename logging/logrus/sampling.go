@@ -0,0 +1,85 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logrus
+
+import (
+	"hash/fnv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SamplingFormatter keeps only a fraction of entries of chatty loggers or levels (e.g. 1% of debug entries),
+// to get a representative trace without the full volume. Whether an entry is kept is decided by a hash
+// of its level, message and fields, so the same event is consistently either kept or dropped. Warnings and
+// more severe entries are never dropped.
+//
+//	logger.SetFormatter(NewSamplingFormatter(NewFormatter(), map[logrus.Level]float64{logrus.DebugLevel: 0.01}))
+//
+// Rates must not be modified once the formatter is in use.
+type SamplingFormatter struct {
+	// Formatter formats the kept entries
+	Formatter logrus.Formatter
+	// Rates maps levels to the fraction of kept entries (0.0 - 1.0), levels without a rate are not sampled
+	Rates map[logrus.Level]float64
+	// LoggerRates maps logger names to the fraction of kept entries of the logger and its descendants
+	// (see LogRegistry.SetLevel), taking precedence over Rates
+	LoggerRates map[string]float64
+}
+
+// NewSamplingFormatter returns a formatter sampling entries of levels with given rates.
+func NewSamplingFormatter(formatter logrus.Formatter, rates map[logrus.Level]float64) *SamplingFormatter {
+	return &SamplingFormatter{
+		Formatter: formatter,
+		Rates:     rates,
+	}
+}
+
+// Format formats the entry, or returns no data if the entry is dropped.
+func (f *SamplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if rate, ok := f.rate(entry); ok && !sampled(entry, rate) {
+		return []byte{}, nil
+	}
+	return f.Formatter.Format(entry)
+}
+
+// rate returns the sampling rate of the entry, or false if the entry is not sampled
+func (f *SamplingFormatter) rate(entry *logrus.Entry) (float64, bool) {
+	if entry.Level <= logrus.WarnLevel {
+		return 0, false
+	}
+	if name, ok := entry.Data[LoggerKey].(string); ok && len(f.LoggerRates) > 0 {
+		for ; name != ""; name = parentLogger(name) {
+			if rate, ok := f.LoggerRates[name]; ok {
+				return rate, true
+			}
+		}
+	}
+	rate, ok := f.Rates[entry.Level]
+	return rate, ok
+}
+
+// sampled returns true if the entry falls into the kept fraction given by the rate
+func sampled(entry *logrus.Entry, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(entrySignature(entry)))
+	// map the hash uniformly to [0, 1)
+	return float64(h.Sum64()>>11)/(1<<53) < rate
+}
@@ -0,0 +1,67 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logrus
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSamplingFormatter(t *testing.T) {
+	RegisterTestingT(t)
+
+	formatter := NewSamplingFormatter(&LogfmtFormatter{}, map[logrus.Level]float64{
+		logrus.DebugLevel: 0.1,
+		logrus.ErrorLevel: 0,
+	})
+	formatter.LoggerRates = map[string]float64{"quiet": 0, "quiet.verbose": 1}
+	kept := func(level logrus.Level, logger, msg string) bool {
+		data, err := formatter.Format(&logrus.Entry{
+			Level:   level,
+			Message: msg,
+			Data:    logrus.Fields{LoggerKey: logger},
+		})
+		Expect(err).To(BeNil())
+		return len(data) > 0
+	}
+
+	var count int
+	for i := 0; i < 10000; i++ {
+		if kept(logrus.DebugLevel, "app", fmt.Sprintf("event %d", i)) {
+			count++
+		}
+	}
+	Expect(count).To(BeNumerically("~", 1000, 150))
+
+	// the decision is deterministic
+	for i := 0; i < 100; i++ {
+		msg := fmt.Sprintf("event %d", i)
+		Expect(kept(logrus.DebugLevel, "app", msg)).To(Equal(kept(logrus.DebugLevel, "app", msg)))
+	}
+
+	// levels without rate are not sampled, warnings and errors are never dropped
+	Expect(kept(logrus.InfoLevel, "app", "info")).To(BeTrue())
+	Expect(kept(logrus.ErrorLevel, "app", "error")).To(BeTrue())
+	Expect(kept(logrus.WarnLevel, "quiet", "warning")).To(BeTrue())
+
+	// logger rates apply to descendants and take precedence over level rates
+	Expect(kept(logrus.InfoLevel, "quiet.child", "info")).To(BeFalse())
+	for i := 0; i < 100; i++ {
+		Expect(kept(logrus.DebugLevel, "quiet.verbose.child", fmt.Sprintf("event %d", i))).To(BeTrue())
+	}
+}
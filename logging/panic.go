@@ -0,0 +1,73 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Field names of the panic entry logged by LogPanic
+const (
+	PanicKey = "panic"
+	StackKey = "stack"
+)
+
+// maxStackDepth limits the number of frames logged by LogPanic
+const maxStackDepth = 64
+
+// LogPanic logs the value recovered from a panic at Error level (using a Logger or an entry
+// with extra fields), along with the stack of the panicking goroutine. The stack is logged
+// as a list of "function file:line" frames under the StackKey field, so it can be searched
+// in aggregated logs. Call it directly from the deferred function which recovered:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			logging.LogPanic(log, r)
+//		}
+//	}()
+func LogPanic(l LogWithLevel, recovered interface{}) {
+	l.WithFields(Fields{
+		PanicKey: fmt.Sprint(recovered),
+		StackKey: panicStack(),
+	}).Errorf("recovered from panic: %v", recovered)
+}
+
+// panicStack returns symbolized frames starting at the place of panic
+func panicStack() []string {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []string
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			// drop deferred function frames above the panic
+			stack = stack[:0]
+		} else {
+			stack = append(stack, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	// runtime frames (goexit) are not interesting
+	for len(stack) > 0 && strings.HasPrefix(stack[len(stack)-1], "runtime.") {
+		stack = stack[:len(stack)-1]
+	}
+	return stack
+}
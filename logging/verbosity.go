@@ -0,0 +1,80 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+)
+
+// VerboseLogger is implemented by loggers with verbosity threshold (known from glog/klog).
+// The verbosity is independent of the log level, e.g. log.Verbose(2).Info(...) is logged
+// only if the logger's verbosity is at least 2 and its level at least info.
+type VerboseLogger interface {
+	// Verbose returns the logger if its verbosity threshold is at least n, otherwise
+	// it returns DiscardLog.
+	Verbose(n int) LogWithLevel
+	// SetVerbosity sets the verbosity threshold used by Verbose
+	SetVerbosity(v int)
+	// GetVerbosity returns the verbosity threshold used by Verbose
+	GetVerbosity() int
+}
+
+// VerbosityRegistry is implemented by registries which can modify verbosity of their loggers
+type VerbosityRegistry interface {
+	// SetVerbosity modifies verbosity threshold (see VerboseLogger) of selected logger in the registry
+	SetVerbosity(logger string, v int) error
+}
+
+// DiscardLog is a LogWithLevel which discards all entries. It is returned by VerboseLogger.Verbose
+// for verbosity levels above the threshold. Fatal and panic entries are discarded as well, but
+// the program still exits or panics the same way as with any other logger.
+var DiscardLog LogWithLevel = discardLog{}
+
+type discardLog struct{}
+
+func (d discardLog) WithField(key string, value interface{}) LogWithLevel { return d }
+func (d discardLog) WithFields(fields Fields) LogWithLevel                { return d }
+func (d discardLog) WithError(err error) LogWithLevel                     { return d }
+
+func (discardLog) Tracef(format string, args ...interface{})   {}
+func (discardLog) Debugf(format string, args ...interface{})   {}
+func (discardLog) Infof(format string, args ...interface{})    {}
+func (discardLog) Warnf(format string, args ...interface{})    {}
+func (discardLog) Warningf(format string, args ...interface{}) {}
+func (discardLog) Errorf(format string, args ...interface{})   {}
+func (discardLog) Fatalf(format string, args ...interface{})   { os.Exit(1) }
+func (discardLog) Panicf(format string, args ...interface{})   { panic(fmt.Sprintf(format, args...)) }
+func (discardLog) Printf(format string, args ...interface{})   {}
+
+func (discardLog) Trace(args ...interface{})   {}
+func (discardLog) Debug(args ...interface{})   {}
+func (discardLog) Info(args ...interface{})    {}
+func (discardLog) Warn(args ...interface{})    {}
+func (discardLog) Warning(args ...interface{}) {}
+func (discardLog) Error(args ...interface{})   {}
+func (discardLog) Fatal(args ...interface{})   { os.Exit(1) }
+func (discardLog) Panic(args ...interface{})   { panic(fmt.Sprint(args...)) }
+func (discardLog) Print(args ...interface{})   {}
+
+func (discardLog) Traceln(args ...interface{})   {}
+func (discardLog) Debugln(args ...interface{})   {}
+func (discardLog) Infoln(args ...interface{})    {}
+func (discardLog) Println(args ...interface{})   {}
+func (discardLog) Warnln(args ...interface{})    {}
+func (discardLog) Warningln(args ...interface{}) {}
+func (discardLog) Errorln(args ...interface{})   {}
+func (discardLog) Fatalln(args ...interface{})   { os.Exit(1) }
+func (discardLog) Panicln(args ...interface{})   { panic(fmt.Sprintln(args...)) }
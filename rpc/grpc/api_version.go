@@ -0,0 +1,160 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// APIVersionHeader is the metadata key of the API version declared by the client.
+const APIVersionHeader = "api-version"
+
+// healthMethodPrefix is prefix of methods of the standard health service, which are never checked.
+const healthMethodPrefix = "/grpc.health.v1.Health/"
+
+// APIVersionRange defines the range of API versions supported by the server. Versions are dot separated
+// numbers (e.g. "2" or "2.1") compared component by component, missing components are treated as zero.
+type APIVersionRange struct {
+	// Min is the oldest supported version (inclusive), empty means no lower bound
+	Min string
+	// Max is the newest supported version (inclusive), empty means no upper bound
+	Max string
+	// RejectMissing rejects calls without the version metadata, otherwise they are assumed to use
+	// the latest version and are accepted
+	RejectMissing bool
+	// SkipMethods lists full method names (e.g. "/pkg.Service/Method") which are not checked,
+	// methods of the health service are always skipped
+	SkipMethods []string
+}
+
+// UnaryServerInterceptorAPIVersion returns a new unary server interceptor that rejects calls
+// declaring API version out of the supported range with FailedPrecondition.
+func UnaryServerInterceptorAPIVersion(r APIVersionRange) (grpc.UnaryServerInterceptor, error) {
+	check, err := r.checker()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}, nil
+}
+
+// StreamServerInterceptorAPIVersion returns a new stream server interceptor that rejects streams
+// declaring API version out of the supported range with FailedPrecondition.
+func StreamServerInterceptorAPIVersion(r APIVersionRange) (grpc.StreamServerInterceptor, error) {
+	check, err := r.checker()
+	if err != nil {
+		return nil, err
+	}
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := check(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}, nil
+}
+
+func (r APIVersionRange) checker() (func(ctx context.Context, method string) error, error) {
+	var min, max []int
+	var err error
+	if r.Min != "" {
+		if min, err = parseAPIVersion(r.Min); err != nil {
+			return nil, fmt.Errorf("invalid minimal API version: %v", err)
+		}
+	}
+	if r.Max != "" {
+		if max, err = parseAPIVersion(r.Max); err != nil {
+			return nil, fmt.Errorf("invalid maximal API version: %v", err)
+		}
+	}
+	if min != nil && max != nil && compareAPIVersions(min, max) > 0 {
+		return nil, fmt.Errorf("minimal API version %s is greater than maximal %s", r.Min, r.Max)
+	}
+	skip := make(map[string]bool, len(r.SkipMethods))
+	for _, method := range r.SkipMethods {
+		skip[method] = true
+	}
+
+	return func(ctx context.Context, method string) error {
+		if skip[method] || strings.HasPrefix(method, healthMethodPrefix) {
+			return nil
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get(APIVersionHeader)
+		if len(values) == 0 || values[0] == "" {
+			if r.RejectMissing {
+				return status.Errorf(codes.FailedPrecondition, "%s requires %q metadata", method, APIVersionHeader)
+			}
+			return nil
+		}
+		version, err := parseAPIVersion(values[0])
+		if err != nil {
+			return status.Errorf(codes.FailedPrecondition, "invalid API version %q: %v", values[0], err)
+		}
+		if min != nil && compareAPIVersions(version, min) < 0 {
+			return status.Errorf(codes.FailedPrecondition, "API version %s is no longer supported (oldest supported is %s)",
+				values[0], r.Min)
+		}
+		if max != nil && compareAPIVersions(version, max) > 0 {
+			return status.Errorf(codes.FailedPrecondition, "API version %s is not supported yet (newest supported is %s)",
+				values[0], r.Max)
+		}
+		return nil
+	}, nil
+}
+
+// parseAPIVersion parses dot separated version numbers, optional "v" prefix is allowed
+func parseAPIVersion(s string) ([]int, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	version := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%q is not a version number", s)
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+func compareAPIVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
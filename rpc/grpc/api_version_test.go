@@ -0,0 +1,83 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAPIVersion(t *testing.T) {
+	interceptor, err := UnaryServerInterceptorAPIVersion(APIVersionRange{Min: "1.2", Max: "2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	call := func(method, version string) error {
+		ctx := context.Background()
+		if version != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(APIVersionHeader, version))
+		}
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	tests := []struct {
+		version string
+		valid   bool
+	}{
+		{"1.2", true},
+		{"v1.10", true},
+		{"2.0.0", true},
+		{"", true},
+		{"1.1", false},
+		{"2.1", false},
+		{"latest", false},
+	}
+	for _, test := range tests {
+		err := call("/test.Service/Method", test.version)
+		if test.valid && err != nil {
+			t.Errorf("version %q: unexpected error: %v", test.version, err)
+		}
+		if !test.valid && status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("version %q: expected FailedPrecondition, got %v", test.version, err)
+		}
+	}
+
+	// health checks are not subject to the check
+	if err := call("/grpc.health.v1.Health/Check", "3"); err != nil {
+		t.Errorf("unexpected error for health check: %v", err)
+	}
+
+	strict, err := UnaryServerInterceptorAPIVersion(APIVersionRange{Min: "1", RejectMissing: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = strict(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition for missing version, got %v", err)
+	}
+
+	if _, err := UnaryServerInterceptorAPIVersion(APIVersionRange{Min: "3", Max: "2"}); err == nil {
+		t.Errorf("expected error for invalid range")
+	}
+}
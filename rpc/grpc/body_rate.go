@@ -0,0 +1,124 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// BodyReadRate defines the minimum rate at which the request message of unary RPCs must be received.
+// GRPC clients do not declare the size of the request before sending it, so the rate is applied
+// to the fixed Size, i.e. every unary RPC must receive its request within
+// Grace + Size / BytesPerSecond.
+type BodyReadRate struct {
+	// BytesPerSecond is the minimal accepted receive rate
+	BytesPerSecond int
+	// Grace is the time allowed on top of the time computed from the size
+	Grace time.Duration
+	// Size is the request size the receive timeout is computed for, typically the size
+	// of the largest expected request
+	Size int
+}
+
+// timeout returns time within which the request message must be received
+func (r BodyReadRate) timeout() time.Duration {
+	if r.BytesPerSecond <= 0 {
+		return r.Grace
+	}
+	return r.Grace + time.Duration(int64(r.Size)*int64(time.Second)/int64(r.BytesPerSecond))
+}
+
+// NewBodyReadRateHandler returns a stats handler (to be used with grpc.StatsHandler server option) which aborts
+// unary RPCs whose request message is not fully received within the receive timeout (see BodyReadRate). This
+// protects against slow (slowloris-style) clients holding handler goroutines by trickling the request body.
+// The aborted RPC fails with codes.Canceled. The isStream function reports streaming methods, which are not
+// limited since they can legitimately wait for messages.
+func NewBodyReadRateHandler(rate BodyReadRate, isStream func(method string) bool, log logging.Logger) stats.Handler {
+	return &bodyReadRateHandler{
+		rate:     rate,
+		isStream: isStream,
+		log:      log,
+	}
+}
+
+type bodyReadRateHandler struct {
+	rate     BodyReadRate
+	isStream func(method string) bool
+	log      logging.Logger
+}
+
+type bodyReadTimerKey struct{}
+
+// TagRPC starts the timer for receiving the request message.
+func (h *bodyReadRateHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	if h.isStream != nil && h.isStream(info.FullMethodName) {
+		return ctx
+	}
+	timeout := h.rate.timeout()
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(timeout, func() {
+		if h.log != nil {
+			h.log.Warnf("%s aborted, request was not received within %v (min. read rate %d B/s)",
+				info.FullMethodName, timeout, h.rate.BytesPerSecond)
+		}
+		cancel()
+	})
+	return context.WithValue(ctx, bodyReadTimerKey{}, timer)
+}
+
+// HandleRPC stops the timer once the request message is received.
+func (h *bodyReadRateHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	switch s.(type) {
+	case *stats.InPayload, *stats.End:
+		if timer, ok := ctx.Value(bodyReadTimerKey{}).(*time.Timer); ok {
+			timer.Stop()
+		}
+	}
+}
+
+// TagConn is a no-op.
+func (h *bodyReadRateHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn is a no-op.
+func (h *bodyReadRateHandler) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
+// streamMethods returns function reporting streaming methods of the server. Services are
+// looked up on first use, since they are registered after the server is created.
+func streamMethods(srv func() *grpc.Server) func(method string) bool {
+	var once sync.Once
+	var streams map[string]bool
+	return func(method string) bool {
+		once.Do(func() {
+			streams = make(map[string]bool)
+			for name, info := range srv().GetServiceInfo() {
+				for _, m := range info.Methods {
+					if m.IsClientStream || m.IsServerStream {
+						streams["/"+name+"/"+m.Name] = true
+					}
+				}
+			}
+		})
+		return streams[method]
+	}
+}
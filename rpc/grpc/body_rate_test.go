@@ -0,0 +1,168 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestBodyReadRateHandler(t *testing.T) {
+	h := NewBodyReadRateHandler(BodyReadRate{BytesPerSecond: 1000, Grace: 50 * time.Millisecond}, func(method string) bool {
+		return method == "/test.Service/Stream"
+	}, nil)
+
+	// request not received in time
+	ctx := h.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/test.Service/Slow"})
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected slow request to be aborted")
+	}
+
+	// request received in time
+	ctx = h.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/test.Service/Fast"})
+	h.HandleRPC(ctx, &stats.InPayload{})
+	select {
+	case <-ctx.Done():
+		t.Fatalf("expected received request not to be aborted")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// streams are not limited
+	ctx = h.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/test.Service/Stream"})
+	if ctx.Done() != nil {
+		t.Fatalf("expected stream context not to be limited")
+	}
+
+	if timeout := (BodyReadRate{BytesPerSecond: 1000, Grace: time.Second, Size: 2000}).timeout(); timeout != 3*time.Second {
+		t.Errorf("unexpected timeout: %v", timeout)
+	}
+}
+
+func TestBodyReadRateAbortsTricklingClient(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	p := NewPlugin(UseListener(lis), UseBodyReadRate(BodyReadRate{BytesPerSecond: 1000, Grace: 200 * time.Millisecond}))
+	if err := p.Init(); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	healthpb.RegisterHealthServer(p.GetServer(), health.NewServer())
+	if err := p.AfterInit(); err != nil {
+		t.Fatalf("after init failed: %v", err)
+	}
+	defer p.Close()
+
+	// the client speaks HTTP/2 directly, since GRPC clients always send whole messages
+	conn, err := lis.Dial()
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	var writeMu sync.Mutex
+	framer := http2.NewFramer(conn, conn)
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	var headers bytes.Buffer
+	enc := hpack.NewEncoder(&headers)
+	for _, field := range []hpack.HeaderField{
+		{Name: ":method", Value: "POST"},
+		{Name: ":scheme", Value: "http"},
+		{Name: ":path", Value: "/grpc.health.v1.Health/Check"},
+		{Name: ":authority", Value: "bufnet"},
+		{Name: "content-type", Value: "application/grpc"},
+		{Name: "te", Value: "trailers"},
+	} {
+		enc.WriteField(field)
+	}
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := framer.WriteSettings(); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: headers.Bytes(), EndHeaders: true}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	// announce 100 B message and send it one byte every 50ms
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		writeMu.Lock()
+		framer.WriteData(1, false, []byte{0, 0, 0, 0, 100})
+		writeMu.Unlock()
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+			writeMu.Lock()
+			err := framer.WriteData(1, false, []byte{0})
+			writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	conn.SetReadDeadline(start.Add(3 * time.Second))
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			t.Fatalf("RPC was not aborted: %v", err)
+		}
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				writeMu.Lock()
+				framer.WriteSettingsAck()
+				writeMu.Unlock()
+			}
+		case *http2.MetaHeadersFrame:
+			if !f.StreamEnded() {
+				continue
+			}
+			if code := f.PseudoValue("status"); code != "" && code != "200" {
+				t.Fatalf("unexpected HTTP status %s", code)
+			}
+			var code string
+			for _, field := range f.RegularFields() {
+				if field.Name == "grpc-status" {
+					code = field.Value
+				}
+			}
+			if code != "1" {
+				t.Fatalf("expected status Canceled (1), got %q", code)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("RPC was aborted too late (%v)", elapsed)
+			}
+			return
+		case *http2.RSTStreamFrame:
+			t.Fatalf("unexpected stream reset: %v", f.ErrCode)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptorCancellation returns a new unary server interceptor that reports calls failed due to
+// client cancellation or deadline with Canceled or DeadlineExceeded status, instead of Unknown or Internal
+// status handlers commonly return for context errors. Client disconnects then do not count as server errors
+// in metrics and logs of the interceptors following in the chain.
+func UnaryServerInterceptorCancellation() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, translateCancellation(ctx, err)
+	}
+}
+
+// StreamServerInterceptorCancellation returns a new stream server interceptor that reports streams failed due
+// to client cancellation or deadline with Canceled or DeadlineExceeded status.
+func StreamServerInterceptorCancellation() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return translateCancellation(stream.Context(), handler(srv, stream))
+	}
+}
+
+// translateCancellation maps context errors, and Unknown or Internal errors of calls with done context,
+// to Canceled or DeadlineExceeded status
+func translateCancellation(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	cause := err
+	switch status.Code(err) {
+	case codes.Unknown, codes.Internal:
+		if ctx.Err() != nil {
+			cause = ctx.Err()
+		}
+	}
+	switch cause {
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCancellationStatus(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		code codes.Code
+	}{
+		{"success", cancelled, nil, codes.OK},
+		{"context error", cancelled, context.Canceled, codes.Canceled},
+		{"wrapped context error", cancelled, status.Error(codes.Internal, "query aborted"), codes.Canceled},
+		{"deadline error", expired, context.DeadlineExceeded, codes.DeadlineExceeded},
+		{"unknown error after deadline", expired, errors.New("read failed"), codes.DeadlineExceeded},
+		{"explicit status", cancelled, status.Error(codes.NotFound, "not found"), codes.NotFound},
+		{"internal error", context.Background(), status.Error(codes.Internal, "failure"), codes.Internal},
+	}
+	interceptor := UnaryServerInterceptorCancellation()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := interceptor(test.ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return nil, test.err
+				})
+			if code := status.Code(err); code != test.code {
+				t.Errorf("expected code %v, got %v (%v)", test.code, code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BreakerState is a state of the circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets all calls through (normal operation).
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects all calls until the cooldown elapses.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe call through to find out if the target recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig defines when the circuit breaker opens.
+type CircuitBreakerConfig struct {
+	// ErrorRate (0-1) of failed calls within the window which opens the breaker
+	ErrorRate float64
+	// MinRequests is the minimum number of calls within the window before the error rate is evaluated
+	MinRequests int
+	// Window is the period over which the error rate is computed
+	Window time.Duration
+	// Cooldown is the time the breaker stays open before a probe call is allowed
+	Cooldown time.Duration
+}
+
+// DefaultCircuitBreakerConfig opens the breaker when half of at least 10 calls within 10s fail
+// and keeps it open for 5s.
+var DefaultCircuitBreakerConfig = CircuitBreakerConfig{
+	ErrorRate:   0.5,
+	MinRequests: 10,
+	Window:      10 * time.Second,
+	Cooldown:    5 * time.Second,
+}
+
+// CircuitBreaker tracks failures of calls to a single target.
+type CircuitBreaker struct {
+	cfg CircuitBreakerConfig
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	// generation changes with every state transition, results of calls allowed
+	// in a previous generation are ignored
+	generation uint64
+}
+
+// NewCircuitBreaker returns a closed circuit breaker.
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{cfg: cfg}
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cfg.Cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow returns true if the call can proceed, together with the token identifying
+// the call when its result is recorded
+func (b *CircuitBreaker) allow() (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cfg.Cooldown {
+			return 0, false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = false
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return 0, false
+		}
+		b.probing = true
+	}
+	return b.generation, true
+}
+
+// record updates the breaker with result of the call identified by the token returned by allow.
+// Results of calls allowed before the last state transition are ignored, so that in half-open state
+// only the probe call decides whether the breaker closes or re-opens.
+func (b *CircuitBreaker) record(token uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if token != b.generation {
+		return
+	}
+	now := time.Now()
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		if failed {
+			b.setState(BreakerOpen)
+			b.openedAt = now
+		} else {
+			b.reset(now)
+		}
+		return
+	}
+	if b.state == BreakerOpen {
+		return
+	}
+	if now.Sub(b.windowStart) > b.cfg.Window {
+		b.windowStart, b.requests, b.failures = now, 0, 0
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= b.cfg.MinRequests && float64(b.failures)/float64(b.requests) >= b.cfg.ErrorRate {
+		b.setState(BreakerOpen)
+		b.openedAt = now
+	}
+}
+
+func (b *CircuitBreaker) setState(state BreakerState) {
+	b.state = state
+	b.generation++
+}
+
+func (b *CircuitBreaker) reset(now time.Time) {
+	b.setState(BreakerClosed)
+	b.windowStart, b.requests, b.failures = now, 0, 0
+}
+
+// isBreakerFailure returns true for errors indicating the target is unhealthy
+func isBreakerFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// CircuitBreakers maintains circuit breakers per target of client connections.
+type CircuitBreakers struct {
+	cfg CircuitBreakerConfig
+
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewCircuitBreakers returns a new set of per-target breakers using given config.
+func NewCircuitBreakers(cfg CircuitBreakerConfig) *CircuitBreakers {
+	return &CircuitBreakers{
+		cfg:      cfg,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Breaker returns circuit breaker for the target, creating it if needed.
+func (c *CircuitBreakers) Breaker(target string) *CircuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[target]
+	if !ok {
+		b = NewCircuitBreaker(c.cfg)
+		c.breakers[target] = b
+	}
+	return b
+}
+
+// States returns states of breakers of all known targets, e.g. for health reporting.
+func (c *CircuitBreakers) States() map[string]BreakerState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	states := make(map[string]BreakerState, len(c.breakers))
+	for target, b := range c.breakers {
+		states[target] = b.State()
+	}
+	return states
+}
+
+// UnaryClientInterceptor returns a new unary client interceptor which short-circuits calls
+// to targets with open breaker with codes.Unavailable error.
+func (c *CircuitBreakers) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		b := c.Breaker(cc.Target())
+		token, ok := b.allow()
+		if !ok {
+			return status.Errorf(codes.Unavailable, "%s is rejected by circuit breaker, target %s is unavailable", method, cc.Target())
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(token, isBreakerFailure(err))
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a new stream client interceptor which short-circuits creation
+// of streams to targets with open breaker with codes.Unavailable error. The result of the stream
+// is recorded when it ends, so streams failing after their creation count as failed calls.
+func (c *CircuitBreakers) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		b := c.Breaker(cc.Target())
+		token, ok := b.allow()
+		if !ok {
+			return nil, status.Errorf(codes.Unavailable, "%s is rejected by circuit breaker, target %s is unavailable", method, cc.Target())
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			b.record(token, isBreakerFailure(err))
+			return nil, err
+		}
+		return &breakerClientStream{
+			ClientStream:  stream,
+			breaker:       b,
+			token:         token,
+			serverStreams: desc.ServerStreams,
+		}, nil
+	}
+}
+
+// breakerClientStream records the result of the stream in the breaker once the stream ends
+type breakerClientStream struct {
+	grpc.ClientStream
+	breaker       *CircuitBreaker
+	token         uint64
+	serverStreams bool
+	once          sync.Once
+}
+
+func (s *breakerClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	case !s.serverStreams:
+		// single response of client-streaming call ends the stream
+		s.finish(nil)
+	}
+	return err
+}
+
+func (s *breakerClientStream) finish(err error) {
+	s.once.Do(func() {
+		s.breaker.record(s.token, isBreakerFailure(err))
+	})
+}
@@ -0,0 +1,178 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerConfig{
+		ErrorRate:   0.5,
+		MinRequests: 4,
+		Window:      time.Minute,
+		Cooldown:    50 * time.Millisecond,
+	})
+
+	var tokens []uint64
+	for i := 0; i < 4; i++ {
+		token, ok := b.allow()
+		if !ok {
+			t.Fatalf("expected call %d to be allowed", i)
+		}
+		tokens = append(tokens, token)
+	}
+	for i := 0; i < 3; i++ {
+		b.record(tokens[i], true)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected breaker to stay closed below min requests, got %v", b.State())
+	}
+	b.record(tokens[3], false)
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected breaker to open, got %v", b.State())
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatalf("expected call to be rejected by open breaker")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("expected breaker to be half-open after cooldown, got %v", b.State())
+	}
+	probe, ok := b.allow()
+	if !ok {
+		t.Fatalf("expected probe call to be allowed")
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatalf("expected only single probe call in half-open state")
+	}
+	b.record(probe, true)
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected failed probe to reopen breaker, got %v", b.State())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	probe, ok = b.allow()
+	if !ok {
+		t.Fatalf("expected probe call to be allowed")
+	}
+	b.record(probe, false)
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected successful probe to close breaker, got %v", b.State())
+	}
+}
+
+func TestCircuitBreakerIgnoresStaleCalls(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerConfig{
+		ErrorRate:   0.5,
+		MinRequests: 1,
+		Window:      time.Minute,
+		Cooldown:    50 * time.Millisecond,
+	})
+
+	stale, _ := b.allow()
+	failing, _ := b.allow()
+	b.record(failing, true)
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected breaker to open, got %v", b.State())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	probe, ok := b.allow()
+	if !ok {
+		t.Fatalf("expected probe call to be allowed")
+	}
+	// call started before the breaker opened does not decide about the half-open breaker
+	b.record(stale, false)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("expected stale call to be ignored, got %v", b.State())
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatalf("expected probe to be still in progress")
+	}
+	b.record(probe, true)
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected failed probe to reopen breaker, got %v", b.State())
+	}
+}
+
+// testClientStream is a client stream returning the given error from RecvMsg
+type testClientStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (s *testClientStream) RecvMsg(m interface{}) error {
+	return s.err
+}
+
+func TestCircuitBreakerStreamFailures(t *testing.T) {
+	cc, err := grpc.Dial("breaker-target", grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer cc.Close()
+
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{
+		ErrorRate:   0.5,
+		MinRequests: 2,
+		Window:      time.Minute,
+		Cooldown:    time.Minute,
+	})
+	interceptor := breakers.StreamClientInterceptor()
+	desc := &grpc.StreamDesc{StreamName: "Watch", ServerStreams: true}
+	openStream := func(recvErr error) error {
+		stream, err := interceptor(context.Background(), desc, cc, "/test.Service/Watch",
+			func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &testClientStream{err: recvErr}, nil
+			})
+		if err != nil {
+			return err
+		}
+		return stream.RecvMsg(nil)
+	}
+
+	// streams ending normally are successful calls
+	for i := 0; i < 2; i++ {
+		if err := openStream(io.EOF); err != io.EOF {
+			t.Fatalf("expected end of stream, got %v", err)
+		}
+	}
+	if state := breakers.Breaker(cc.Target()).State(); state != BreakerClosed {
+		t.Fatalf("expected breaker to stay closed, got %v", state)
+	}
+
+	// streams failing after they were created count as failures
+	unavailable := status.Error(codes.Unavailable, "connection lost")
+	for i := 0; i < 2; i++ {
+		if err := openStream(unavailable); err != unavailable {
+			t.Fatalf("expected stream error, got %v", err)
+		}
+	}
+	if state := breakers.Breaker(cc.Target()).State(); state != BreakerOpen {
+		t.Fatalf("expected failed streams to open breaker, got %v", state)
+	}
+	if err := openStream(io.EOF); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected stream to be rejected by open breaker, got %v", err)
+	}
+}
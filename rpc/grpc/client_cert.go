@@ -0,0 +1,76 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ClientCertMethods is a set of methods which require a verified client certificate. Entries are
+// full method names ("/package.Service/Method"), or service names ending with slash
+// ("/package.Service/") matching all methods of the service.
+type ClientCertMethods []string
+
+func (m ClientCertMethods) requires(fullMethod string) bool {
+	for _, method := range m {
+		if method == fullMethod || strings.HasSuffix(method, "/") && strings.HasPrefix(fullMethod, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasVerifiedClientCert returns true if the peer presented a client certificate verified by the server.
+func hasVerifiedClientCert(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return false
+	}
+	return len(tlsInfo.State.VerifiedChains) > 0 && len(tlsInfo.State.VerifiedChains[0]) > 0
+}
+
+// UnaryServerInterceptorClientCert returns a new unary server interceptor that rejects calls of given methods
+// with Unauthenticated if the client did not present a verified certificate. It allows to enforce mutual TLS
+// for selected methods while the transport only verifies certificates if given (tls.VerifyClientCertIfGiven).
+func UnaryServerInterceptorClientCert(methods ClientCertMethods) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if methods.requires(info.FullMethod) && !hasVerifiedClientCert(ctx) {
+			return nil, status.Errorf(codes.Unauthenticated, "%s requires verified client certificate", info.FullMethod)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptorClientCert returns a new stream server interceptor that rejects streams of given methods
+// with Unauthenticated if the client did not present a verified certificate.
+func StreamServerInterceptorClientCert(methods ClientCertMethods) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if methods.requires(info.FullMethod) && !hasVerifiedClientCert(stream.Context()) {
+			return status.Errorf(codes.Unauthenticated, "%s requires verified client certificate", info.FullMethod)
+		}
+		return handler(srv, stream)
+	}
+}
@@ -0,0 +1,61 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientCertRequired(t *testing.T) {
+	interceptor := UnaryServerInterceptorClientCert(ClientCertMethods{"/test.Admin/", "/test.Service/Write"})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	verified := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}},
+	}})
+	unverified := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		code   codes.Code
+	}{
+		{"verified cert", verified, "/test.Service/Write", codes.OK},
+		{"verified cert for service", verified, "/test.Admin/Reset", codes.OK},
+		{"no cert", unverified, "/test.Service/Write", codes.Unauthenticated},
+		{"no cert for service", unverified, "/test.Admin/Reset", codes.Unauthenticated},
+		{"no TLS", context.Background(), "/test.Service/Write", codes.Unauthenticated},
+		{"optional cert", unverified, "/test.Service/Read", codes.OK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := interceptor(test.ctx, nil, &grpc.UnaryServerInfo{FullMethod: test.method}, handler)
+			if code := status.Code(err); code != test.code {
+				t.Errorf("expected code %v, got %v (%v)", test.code, code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConcurrencyStats describes the current load of the ConcurrencyLimiter.
+type ConcurrencyStats struct {
+	// Active is the number of calls being handled
+	Active int `json:"active"`
+	// Queued is the number of calls waiting for their turn
+	Queued int `json:"queued"`
+	// MaxConcurrent and MaxQueue are the configured limits
+	MaxConcurrent int `json:"max_concurrent"`
+	MaxQueue      int `json:"max_queue"`
+}
+
+// ConcurrencyLimiter limits the number of calls handled concurrently. Calls exceeding the limit wait
+// in a bounded queue for their turn (in order of arrival) up to the max queue wait. Calls which do not fit
+// into the queue, or which waited too long, are rejected with ResourceExhausted status. Calls cancelled
+// by the client leave the queue immediately.
+type ConcurrencyLimiter struct {
+	slots    chan struct{}
+	maxQueue int
+	maxWait  time.Duration
+	queued   int32
+}
+
+// NewConcurrencyLimiter returns a limiter allowing maxConcurrent calls handled at once and maxQueue calls
+// waiting at most maxWait (zero means no limit of the wait other than the call deadline). The maxConcurrent
+// must be positive.
+func NewConcurrencyLimiter(maxConcurrent, maxQueue int, maxWait time.Duration) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		slots:    make(chan struct{}, maxConcurrent),
+		maxQueue: maxQueue,
+		maxWait:  maxWait,
+	}
+}
+
+// Stats returns the current number of active and queued calls.
+func (l *ConcurrencyLimiter) Stats() ConcurrencyStats {
+	return ConcurrencyStats{
+		Active:        len(l.slots),
+		Queued:        int(atomic.LoadInt32(&l.queued)),
+		MaxConcurrent: cap(l.slots),
+		MaxQueue:      l.maxQueue,
+	}
+}
+
+// acquire waits for a free slot, the slot must be released once the call is handled
+func (l *ConcurrencyLimiter) acquire(ctx context.Context, method string) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if queued := atomic.AddInt32(&l.queued, 1); int(queued) > l.maxQueue {
+		atomic.AddInt32(&l.queued, -1)
+		return status.Errorf(codes.ResourceExhausted, "%s rejected: too many concurrent requests", method)
+	}
+	defer atomic.AddInt32(&l.queued, -1)
+
+	var timeout <-chan time.Time
+	if l.maxWait > 0 {
+		timer := time.NewTimer(l.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		return status.Errorf(codes.ResourceExhausted, "%s rejected: queued for more than %v", method, l.maxWait)
+	case <-ctx.Done():
+		return translateCancellation(ctx, ctx.Err())
+	}
+}
+
+func (l *ConcurrencyLimiter) release() {
+	<-l.slots
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor that limits concurrency of calls.
+func (l *ConcurrencyLimiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.acquire(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		defer l.release()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that limits concurrency of streams.
+func (l *ConcurrencyLimiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.acquire(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		defer l.release()
+
+		return handler(srv, stream)
+	}
+}
+
+// Concurrency returns the current load of the server. It returns zero stats unless the concurrency limit
+// is set with UseConcurrencyLimit option.
+func (p *Plugin) Concurrency() ConcurrencyStats {
+	if p.concurrency == nil {
+		return ConcurrencyStats{}
+	}
+	return p.concurrency.Stats()
+}
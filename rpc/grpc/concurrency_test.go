@@ -0,0 +1,125 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, 1, 200*time.Millisecond)
+	interceptor := limiter.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	blocking := func(ctx context.Context, req interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	}
+	call := func(ctx context.Context) <-chan error {
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := interceptor(ctx, nil, info, blocking)
+			errCh <- err
+		}()
+		return errCh
+	}
+	waitFor := func(expected ConcurrencyStats) {
+		deadline := time.Now().Add(time.Second)
+		for limiter.Stats() != expected && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if stats := limiter.Stats(); stats != expected {
+			t.Fatalf("expected stats %+v, got %+v", expected, stats)
+		}
+	}
+
+	// the first call is handled, the second is queued and the third is rejected
+	first := call(context.Background())
+	<-started
+	second := call(context.Background())
+	waitFor(ConcurrencyStats{Active: 1, Queued: 1, MaxConcurrent: 1, MaxQueue: 1})
+	if _, err := interceptor(context.Background(), nil, info, blocking); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted for full queue, got %v", err)
+	}
+
+	// the queued call proceeds once the first finishes
+	release <- struct{}{}
+	if err := <-first; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	<-started
+	waitFor(ConcurrencyStats{Active: 1, Queued: 0, MaxConcurrent: 1, MaxQueue: 1})
+
+	// queued calls are rejected after the max wait, or leave the queue once cancelled
+	if err := <-call(context.Background()); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted after queue wait, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := call(ctx)
+	waitFor(ConcurrencyStats{Active: 1, Queued: 1, MaxConcurrent: 1, MaxQueue: 1})
+	cancel()
+	select {
+	case err := <-cancelled:
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("expected Canceled for cancelled call, got %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("cancelled call did not leave the queue")
+	}
+	waitFor(ConcurrencyStats{Active: 1, Queued: 0, MaxConcurrent: 1, MaxQueue: 1})
+
+	release <- struct{}{}
+	if err := <-second; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	waitFor(ConcurrencyStats{MaxConcurrent: 1, MaxQueue: 1})
+}
+
+func TestConcurrencyLimitValidation(t *testing.T) {
+	for _, limit := range []struct {
+		maxConcurrent, maxQueue int
+		maxWait                 time.Duration
+	}{
+		{0, 1, time.Second},
+		{-1, 1, time.Second},
+		{1, -1, time.Second},
+		{1, 1, -time.Second},
+	} {
+		p := NewPlugin(UseListener(bufconn.Listen(1024)), UseConcurrencyLimit(limit.maxConcurrent, limit.maxQueue, limit.maxWait))
+		if err := p.Init(); err == nil {
+			p.Close()
+			t.Errorf("expected init to fail for limit %+v", limit)
+		}
+	}
+
+	p := NewPlugin(UseListener(bufconn.Listen(1024)), UseConcurrencyLimit(2, 0, 0))
+	if err := p.Init(); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	defer p.Close()
+	if stats := p.Concurrency(); stats.MaxConcurrent != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
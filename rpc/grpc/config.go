@@ -59,7 +59,7 @@ type Config struct {
 	Keyfile           string   `json:"key-file"`
 	CAfiles           []string `json:"ca-files"`
 
-	// ExtendedLogging enables detailed GRPC logging
+	// ExtendedLogging enables detailed GRPC logging (mirrored into the grpc-server logger)
 	ExtendedLogging bool `json:"extended-logging"`
 
 	// PrometheusMetrics enables prometheus metrics for gRPC client.
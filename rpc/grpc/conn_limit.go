@@ -0,0 +1,126 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// ErrConnByteLimit is returned from reads of a connection which exceeded the byte limit.
+var ErrConnByteLimit = errors.New("connection byte limit exceeded")
+
+// ConnBytes is the number of bytes received on a connection.
+type ConnBytes struct {
+	RemoteAddr string
+	Bytes      int64
+}
+
+// ConnByteLimiter limits the total number of bytes received on each connection. Unlike the maximum
+// message size, it guards long-lived streams: once the cumulative count exceeds the limit, the connection
+// is closed, failing all calls on it. Counts are removed when the connection is closed.
+type ConnByteLimiter struct {
+	limit int64
+	log   logging.Logger
+
+	mu    sync.Mutex
+	conns map[*countingConn]struct{}
+}
+
+// NewConnByteLimiter returns a limiter closing connections which received more than limit bytes.
+func NewConnByteLimiter(limit int64, log logging.Logger) *ConnByteLimiter {
+	return &ConnByteLimiter{
+		limit: limit,
+		log:   log,
+		conns: make(map[*countingConn]struct{}),
+	}
+}
+
+// Listener wraps the listener, so that bytes received on accepted connections are counted and limited.
+func (l *ConnByteLimiter) Listener(lis net.Listener) net.Listener {
+	return &countingListener{Listener: lis, limiter: l}
+}
+
+// Connections returns the number of bytes received on currently open connections, the largest first.
+func (l *ConnByteLimiter) Connections() []ConnBytes {
+	l.mu.Lock()
+	conns := make([]ConnBytes, 0, len(l.conns))
+	for c := range l.conns {
+		conns = append(conns, ConnBytes{
+			RemoteAddr: c.RemoteAddr().String(),
+			Bytes:      atomic.LoadInt64(&c.bytes),
+		})
+	}
+	l.mu.Unlock()
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].Bytes > conns[j].Bytes
+	})
+	return conns
+}
+
+func (l *ConnByteLimiter) remove(c *countingConn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.conns, c)
+}
+
+type countingListener struct {
+	net.Listener
+	limiter *ConnByteLimiter
+}
+
+func (lis *countingListener) Accept() (net.Conn, error) {
+	conn, err := lis.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	c := &countingConn{Conn: conn, limiter: lis.limiter}
+	lis.limiter.mu.Lock()
+	lis.limiter.conns[c] = struct{}{}
+	lis.limiter.mu.Unlock()
+	return c, nil
+}
+
+type countingConn struct {
+	net.Conn
+	limiter *ConnByteLimiter
+	bytes   int64
+	once    sync.Once
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if total := atomic.AddInt64(&c.bytes, int64(n)); total > c.limiter.limit {
+		if c.limiter.log != nil {
+			c.limiter.log.Warnf("Closing GRPC connection from %v, received %d bytes (limit %d)",
+				c.RemoteAddr(), total, c.limiter.limit)
+		}
+		c.Close()
+		return 0, ErrConnByteLimit
+	}
+	return n, err
+}
+
+func (c *countingConn) Close() error {
+	c.once.Do(func() {
+		c.limiter.remove(c)
+	})
+	return c.Conn.Close()
+}
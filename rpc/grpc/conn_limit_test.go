@@ -0,0 +1,78 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestConnByteLimit(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	p := NewPlugin(UseListener(lis), UseConnByteLimit(16*1024))
+	if err := p.Init(); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	defer p.Close()
+	healthpb.RegisterHealthServer(p.GetServer(), health.NewServer())
+	if err := p.AfterInit(); err != nil {
+		t.Fatalf("after init failed: %v", err)
+	}
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	// unknown service, so that the call gets past the handler with large request
+	req := &healthpb.HealthCheckRequest{Service: strings.Repeat("x", 4*1024)}
+	if _, err := client.Check(context.Background(), req); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound error, got %v", err)
+	}
+	conns := p.ConnectionBytes()
+	if len(conns) != 1 || conns[0].Bytes < 4*1024 {
+		t.Fatalf("unexpected connection bytes: %+v", conns)
+	}
+
+	var lastErr error
+	for i := 0; i < 5 && lastErr == nil; i++ {
+		_, err := client.Check(context.Background(), req)
+		if status.Code(err) != codes.NotFound {
+			lastErr = err
+		}
+	}
+	if status.Code(lastErr) != codes.Unavailable {
+		t.Fatalf("expected Unavailable error after exceeding the limit, got %v", lastErr)
+	}
+	for _, c := range p.ConnectionBytes() {
+		if c.Bytes > 16*1024 {
+			t.Errorf("closed connection still tracked: %+v", c)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// MetadataCredentials are per-RPC credentials attaching static metadata to every call. Unlike most
+// per-RPC credentials they do not require transport security, so they can be used for in-process
+// (loopback) clients, whose calls then pass through the same auth interceptors as external calls.
+type MetadataCredentials map[string]string
+
+var _ credentials.PerRPCCredentials = MetadataCredentials(nil)
+
+// BearerTokenCredentials returns credentials with the token accepted by Authenticator.
+func BearerTokenCredentials(token string) MetadataCredentials {
+	return MetadataCredentials{"authorization": "Bearer " + token}
+}
+
+// BasicAuthCredentials returns credentials with the user and password accepted by Authenticator.
+func BasicAuthCredentials(user, password string) MetadataCredentials {
+	auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+	return MetadataCredentials{"authorization": "Basic " + auth}
+}
+
+// GetRequestMetadata returns the metadata attached to every call.
+func (c MetadataCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return c, nil
+}
+
+// RequireTransportSecurity returns false, the metadata can be sent over insecure connections.
+func (c MetadataCredentials) RequireTransportSecurity() bool {
+	return false
+}
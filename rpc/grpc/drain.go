@@ -0,0 +1,157 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPushbackTrailer is the trailer key of the retry hint (as defined by gRPC retry design)
+// sent with calls rejected during drain. Zero value lets clients retry immediately on another backend.
+const RetryPushbackTrailer = "grpc-retry-pushback-ms"
+
+// DrainCoordinator coordinates graceful shutdown of the server: it reports the server as not serving
+// via the health service, rejects new calls with Unavailable and waits for in-flight calls to finish.
+// Its interceptors must be installed on the server (see UseDrain).
+type DrainCoordinator struct {
+	health         *health.Server
+	registerHealth bool
+	delay          time.Duration
+
+	mu       sync.Mutex
+	draining bool
+	active   int
+	idle     chan struct{}
+}
+
+// NewDrainCoordinator creates a new drain coordinator for the health server. The delay is the time
+// between reporting NOT_SERVING and rejecting new calls, which gives load balancers time to notice
+// the change and redirect traffic.
+func NewDrainCoordinator(health *health.Server, delay time.Duration) *DrainCoordinator {
+	return &DrainCoordinator{
+		health: health,
+		delay:  delay,
+	}
+}
+
+// Draining returns true once the drain started.
+func (d *DrainCoordinator) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// Drain reports all services of the server as NOT_SERVING, waits for the delay, starts rejecting
+// new calls and then waits until in-flight calls finish or the context is done.
+func (d *DrainCoordinator) Drain(ctx context.Context, srv *grpc.Server) error {
+	if d.health != nil {
+		d.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+		for service := range srv.GetServiceInfo() {
+			d.health.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+		}
+	}
+	if d.delay > 0 {
+		timer := time.NewTimer(d.delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+
+	d.mu.Lock()
+	d.draining = true
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+		if d.active == 0 {
+			close(d.idle)
+		}
+	}
+	idle := d.idle
+	d.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor rejecting new calls during drain.
+func (d *DrainCoordinator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+			return handler(ctx, req)
+		}
+		if !d.begin() {
+			_ = grpc.SetTrailer(ctx, metadata.Pairs(RetryPushbackTrailer, "0"))
+			return nil, errDraining(info.FullMethod)
+		}
+		defer d.end()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor rejecting new streams during drain.
+func (d *DrainCoordinator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+			return handler(srv, stream)
+		}
+		if !d.begin() {
+			stream.SetTrailer(metadata.Pairs(RetryPushbackTrailer, "0"))
+			return errDraining(info.FullMethod)
+		}
+		defer d.end()
+		return handler(srv, stream)
+	}
+}
+
+// begin registers a new call, returns false if the call is rejected. Health checks are not
+// registered and always allowed, so that load balancers can observe the status.
+func (d *DrainCoordinator) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.active++
+	return true
+}
+
+func (d *DrainCoordinator) end() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.active == 0 && d.idle != nil {
+		close(d.idle)
+	}
+}
+
+func errDraining(method string) error {
+	return status.Errorf(codes.Unavailable, "%s rejected, server is shutting down, please retry on another instance", method)
+}
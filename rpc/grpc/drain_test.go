@@ -0,0 +1,98 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestDrain(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	p := NewPlugin(UseListener(lis), UseDrain(nil, 0))
+	if err := p.Init(); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	defer p.Close()
+	if err := p.AfterInit(); err != nil {
+		t.Fatalf("after init failed: %v", err)
+	}
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	// simulate in-flight call
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Slow"}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go p.drain.UnaryServerInterceptor()(context.Background(), nil, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+	<-started
+
+	drained := make(chan error)
+	go func() {
+		drained <- p.drain.Drain(context.Background(), p.GetServer())
+	}()
+	for !p.drain.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING health status, got %v (err: %v)", resp, err)
+	}
+
+	_, err = p.drain.UnaryServerInterceptor()(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Errorf("new call must not be handled during drain")
+		return nil, nil
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable during drain, got %v", err)
+	}
+
+	select {
+	case <-drained:
+		t.Fatalf("drain finished before in-flight call")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-drained; err != nil {
+		t.Errorf("drain failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Drain(ctx); err != nil {
+		t.Errorf("plugin drain failed: %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+// MethodInfo describes a method discovered using server reflection.
+type MethodInfo struct {
+	// FullMethod is the method name in the form "/package.Service/Method"
+	FullMethod string
+	// InputType and OutputType are fully-qualified message names
+	InputType  string
+	OutputType string
+
+	ClientStreaming bool
+	ServerStreaming bool
+}
+
+// DynamicClient calls methods of a server discovered using server reflection (see UseReflection),
+// with requests and responses encoded as JSON. Neither generated client stubs nor message types are needed,
+// messages are built from the file descriptors (including their dependencies) fetched from the server.
+type DynamicClient struct {
+	conn *grpc.ClientConn
+}
+
+// NewDynamicClient returns a new dynamic client using the connection.
+func NewDynamicClient(conn *grpc.ClientConn) *DynamicClient {
+	return &DynamicClient{conn: conn}
+}
+
+// ListServices returns names of services provided by the server.
+func (c *DynamicClient) ListServices(ctx context.Context) ([]string, error) {
+	resp, err := c.reflect(ctx, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var services []string
+	for _, svc := range resp.GetListServicesResponse().GetService() {
+		services = append(services, svc.GetName())
+	}
+	return services, nil
+}
+
+// ListMethods returns methods of the service with given fully-qualified name.
+func (c *DynamicClient) ListMethods(ctx context.Context, service string) ([]MethodInfo, error) {
+	svc, pkg, _, err := c.resolveService(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	var methods []MethodInfo
+	for _, m := range svc.GetMethod() {
+		methods = append(methods, methodInfo(pkg, svc, m))
+	}
+	return methods, nil
+}
+
+// DescribeMethod returns description of the method given as "package.Service/Method" (leading slash is optional).
+func (c *DynamicClient) DescribeMethod(ctx context.Context, method string) (*MethodInfo, error) {
+	info, _, err := c.describeMethod(ctx, method)
+	return info, err
+}
+
+// Invoke calls the unary method given as "package.Service/Method" with the request decoded from JSON
+// and returns the response encoded as JSON.
+func (c *DynamicClient) Invoke(ctx context.Context, method string, request []byte) ([]byte, error) {
+	info, pool, err := c.describeMethod(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	if info.ClientStreaming || info.ServerStreaming {
+		return nil, status.Errorf(codes.Unimplemented, "streaming method %s is not supported", info.FullMethod)
+	}
+	if err := c.loadDependencies(ctx, pool); err != nil {
+		return nil, err
+	}
+	reqType, err := pool.message(info.InputType)
+	if err != nil {
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	respType, err := pool.message(info.OutputType)
+	if err != nil {
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	req, resp := &dynamicMessage{}, &dynamicMessage{}
+	if len(bytes.TrimSpace(request)) > 0 {
+		if req.data, err = pool.encodeJSON(reqType, request); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request for %s: %v", info.FullMethod, err)
+		}
+	}
+	if err := c.conn.Invoke(ctx, info.FullMethod, req, resp); err != nil {
+		return nil, err
+	}
+	out, err := pool.decodeJSON(respType, resp.data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %v", err)
+	}
+	return out, nil
+}
+
+// describeMethod returns description of the method together with descriptors of the service
+func (c *DynamicClient) describeMethod(ctx context.Context, method string) (*MethodInfo, *descriptorPool, error) {
+	service, name, err := splitMethod(method)
+	if err != nil {
+		return nil, nil, err
+	}
+	svc, pkg, pool, err := c.resolveService(ctx, service)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range svc.GetMethod() {
+		if m.GetName() == name {
+			info := methodInfo(pkg, svc, m)
+			return &info, pool, nil
+		}
+	}
+	return nil, nil, status.Errorf(codes.NotFound, "method %s not found in service %s", name, service)
+}
+
+// reflect sends single request over the reflection stream
+func (c *DynamicClient) reflect(ctx context.Context, req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := rpb.NewServerReflectionClient(c.conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return nil, status.Error(codes.Code(errResp.GetErrorCode()), errResp.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// resolveService fetches descriptor of the service and returns it with its package name
+// and the pool of fetched descriptors
+func (c *DynamicClient) resolveService(ctx context.Context, service string) (*dpb.ServiceDescriptorProto, string, *descriptorPool, error) {
+	pool := newDescriptorPool()
+	err := c.fetchFiles(ctx, pool, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	})
+	if err != nil {
+		return nil, "", nil, err
+	}
+	for _, fd := range pool.files {
+		for _, svc := range fd.GetService() {
+			if qualifiedName(fd.GetPackage(), svc.GetName()) == service {
+				return svc, fd.GetPackage(), pool, nil
+			}
+		}
+	}
+	return nil, "", nil, status.Errorf(codes.NotFound, "service %s not found", service)
+}
+
+// loadDependencies fetches all files the files in the pool depend on (transitively)
+func (c *DynamicClient) loadDependencies(ctx context.Context, pool *descriptorPool) error {
+	for missing := pool.missingDependencies(); len(missing) > 0; missing = pool.missingDependencies() {
+		for _, name := range missing {
+			err := c.fetchFiles(ctx, pool, &rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to fetch dependency %s: %v", name, err)
+			}
+			if _, ok := pool.files[name]; !ok {
+				return fmt.Errorf("dependency %s not returned by the server", name)
+			}
+		}
+	}
+	return nil
+}
+
+// fetchFiles adds file descriptors returned for the reflection request to the pool
+func (c *DynamicClient) fetchFiles(ctx context.Context, pool *descriptorPool, req *rpb.ServerReflectionRequest) error {
+	resp, err := c.reflect(ctx, req)
+	if err != nil {
+		return err
+	}
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := new(dpb.FileDescriptorProto)
+		if err := proto.Unmarshal(raw, fd); err != nil {
+			return fmt.Errorf("invalid file descriptor: %v", err)
+		}
+		pool.add(fd)
+	}
+	return nil
+}
+
+func methodInfo(pkg string, svc *dpb.ServiceDescriptorProto, m *dpb.MethodDescriptorProto) MethodInfo {
+	return MethodInfo{
+		FullMethod:      "/" + qualifiedName(pkg, svc.GetName()) + "/" + m.GetName(),
+		InputType:       strings.TrimPrefix(m.GetInputType(), "."),
+		OutputType:      strings.TrimPrefix(m.GetOutputType(), "."),
+		ClientStreaming: m.GetClientStreaming(),
+		ServerStreaming: m.GetServerStreaming(),
+	}
+}
+
+func qualifiedName(pkg, name string) string {
+	if pkg == "" {
+		return name
+	}
+	return pkg + "." + name
+}
+
+func splitMethod(method string) (service, name string, err error) {
+	method = strings.TrimPrefix(method, "/")
+	i := strings.LastIndex(method, "/")
+	if i <= 0 || i == len(method)-1 {
+		return "", "", status.Errorf(codes.InvalidArgument, "invalid method %q, expected package.Service/Method", method)
+	}
+	return method[:i], method[i+1:], nil
+}
@@ -0,0 +1,215 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestDynamicClient(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	client := NewDynamicClient(conn)
+
+	services, err := client.ListServices(ctx)
+	if err != nil {
+		t.Fatalf("listing services failed: %v", err)
+	}
+	var found bool
+	for _, svc := range services {
+		found = found || svc == "grpc.health.v1.Health"
+	}
+	if !found {
+		t.Fatalf("health service not listed: %v", services)
+	}
+
+	methods, err := client.ListMethods(ctx, "grpc.health.v1.Health")
+	if err != nil || len(methods) == 0 || methods[0].FullMethod != "/grpc.health.v1.Health/Check" {
+		t.Fatalf("unexpected methods: %+v (err: %v)", methods, err)
+	}
+
+	resp, err := client.Invoke(ctx, "grpc.health.v1.Health/Check", []byte(`{"service": ""}`))
+	if err != nil {
+		t.Fatalf("invoke failed: %v", err)
+	}
+	if string(resp) != `{"status":"SERVING"}` {
+		t.Errorf("unexpected response: %s", resp)
+	}
+
+	if _, err := client.Invoke(ctx, "grpc.health.v1.Health/Unknown", nil); err == nil {
+		t.Errorf("expected error for unknown method")
+	}
+}
+
+// registerTestFile registers the file descriptor with the proto package (as generated code does),
+// without registering any Go types for its messages
+func registerTestFile(t *testing.T, fd *dpb.FileDescriptorProto) {
+	raw, err := proto.Marshal(fd)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(raw)
+	w.Close()
+	proto.RegisterFile(fd.GetName(), buf.Bytes())
+}
+
+func TestDynamicClientUnregisteredTypes(t *testing.T) {
+	field := func(name string, num int32, typ dpb.FieldDescriptorProto_Type, typeName string) *dpb.FieldDescriptorProto {
+		f := &dpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(num),
+			Type:   typ.Enum(),
+			Label:  dpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	repeated := func(f *dpb.FieldDescriptorProto) *dpb.FieldDescriptorProto {
+		f.Label = dpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return f
+	}
+	registerTestFile(t, &dpb.FileDescriptorProto{
+		Name:    proto.String("cninfra/dyntest/common.proto"),
+		Package: proto.String("cninfra.dyntest.common"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*dpb.DescriptorProto{{
+			Name: proto.String("Meta"),
+			Field: []*dpb.FieldDescriptorProto{
+				field("owner", 1, dpb.FieldDescriptorProto_TYPE_STRING, ""),
+				repeated(field("counters", 2, dpb.FieldDescriptorProto_TYPE_MESSAGE, ".cninfra.dyntest.common.Meta.CountersEntry")),
+				repeated(field("tags", 3, dpb.FieldDescriptorProto_TYPE_INT32, "")),
+			},
+			NestedType: []*dpb.DescriptorProto{{
+				Name: proto.String("CountersEntry"),
+				Field: []*dpb.FieldDescriptorProto{
+					field("key", 1, dpb.FieldDescriptorProto_TYPE_STRING, ""),
+					field("value", 2, dpb.FieldDescriptorProto_TYPE_INT64, ""),
+				},
+				Options: &dpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+		}},
+		EnumType: []*dpb.EnumDescriptorProto{{
+			Name: proto.String("Priority"),
+			Value: []*dpb.EnumValueDescriptorProto{
+				{Name: proto.String("LOW"), Number: proto.Int32(0)},
+				{Name: proto.String("HIGH"), Number: proto.Int32(1)},
+			},
+		}},
+	})
+	registerTestFile(t, &dpb.FileDescriptorProto{
+		Name:       proto.String("cninfra/dyntest/echo.proto"),
+		Package:    proto.String("cninfra.dyntest"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"cninfra/dyntest/common.proto"},
+		MessageType: []*dpb.DescriptorProto{{
+			Name: proto.String("EchoMessage"),
+			Field: []*dpb.FieldDescriptorProto{
+				field("text", 1, dpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("meta", 2, dpb.FieldDescriptorProto_TYPE_MESSAGE, ".cninfra.dyntest.common.Meta"),
+				field("priority", 3, dpb.FieldDescriptorProto_TYPE_ENUM, ".cninfra.dyntest.common.Priority"),
+				field("delta", 4, dpb.FieldDescriptorProto_TYPE_SINT64, ""),
+				field("payload", 5, dpb.FieldDescriptorProto_TYPE_BYTES, ""),
+				field("request_id", 6, dpb.FieldDescriptorProto_TYPE_STRING, ""),
+			},
+		}},
+		Service: []*dpb.ServiceDescriptorProto{{
+			Name: proto.String("Echo"),
+			Method: []*dpb.MethodDescriptorProto{{
+				Name:       proto.String("Echo"),
+				InputType:  proto.String(".cninfra.dyntest.EchoMessage"),
+				OutputType: proto.String(".cninfra.dyntest.EchoMessage"),
+			}},
+		}},
+	})
+	if proto.MessageType("cninfra.dyntest.EchoMessage") != nil {
+		t.Fatalf("message type is not expected to be registered")
+	}
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	// the server echoes the request message in wire format
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "cninfra.dyntest.Echo",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Echo",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				msg := &dynamicMessage{}
+				if err := dec(msg); err != nil {
+					return nil, err
+				}
+				return msg, nil
+			},
+		}},
+		Metadata: "cninfra/dyntest/echo.proto",
+	}, struct{}{})
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	client := NewDynamicClient(conn)
+	request := `{"text":"hello","meta":{"owner":"ops","counters":{"a":"1"},"tags":[1,-2]},` +
+		`"priority":"HIGH","delta":"-5","payload":"aGk=","requestId":"42"}`
+	resp, err := client.Invoke(context.Background(), "cninfra.dyntest.Echo/Echo", []byte(request))
+	if err != nil {
+		t.Fatalf("invoke failed: %v", err)
+	}
+	if string(resp) != request {
+		t.Errorf("unexpected response: %s", resp)
+	}
+
+	if _, err := client.Invoke(context.Background(), "cninfra.dyntest.Echo/Echo", []byte(`{"unknown":1}`)); err == nil {
+		t.Errorf("expected error for unknown field")
+	}
+}
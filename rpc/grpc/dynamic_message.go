@@ -0,0 +1,721 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// descriptorPool indexes types of file descriptors fetched from the server using reflection.
+type descriptorPool struct {
+	files    map[string]*dpb.FileDescriptorProto
+	messages map[string]*dpb.DescriptorProto
+	enums    map[string]*dpb.EnumDescriptorProto
+}
+
+func newDescriptorPool() *descriptorPool {
+	return &descriptorPool{
+		files:    make(map[string]*dpb.FileDescriptorProto),
+		messages: make(map[string]*dpb.DescriptorProto),
+		enums:    make(map[string]*dpb.EnumDescriptorProto),
+	}
+}
+
+// add indexes all messages and enums of the file, including nested ones
+func (p *descriptorPool) add(fd *dpb.FileDescriptorProto) {
+	if _, ok := p.files[fd.GetName()]; ok {
+		return
+	}
+	p.files[fd.GetName()] = fd
+	for _, msg := range fd.GetMessageType() {
+		p.addMessage(fd.GetPackage(), msg)
+	}
+	for _, enum := range fd.GetEnumType() {
+		p.enums[qualifiedName(fd.GetPackage(), enum.GetName())] = enum
+	}
+}
+
+func (p *descriptorPool) addMessage(prefix string, msg *dpb.DescriptorProto) {
+	name := qualifiedName(prefix, msg.GetName())
+	p.messages[name] = msg
+	for _, nested := range msg.GetNestedType() {
+		p.addMessage(name, nested)
+	}
+	for _, enum := range msg.GetEnumType() {
+		p.enums[qualifiedName(name, enum.GetName())] = enum
+	}
+}
+
+// missingDependencies returns names of files imported by the known files, which are not known yet
+func (p *descriptorPool) missingDependencies() []string {
+	var missing []string
+	for _, fd := range p.files {
+		for _, dep := range fd.GetDependency() {
+			if _, ok := p.files[dep]; !ok {
+				missing = append(missing, dep)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func (p *descriptorPool) message(typeName string) (*dpb.DescriptorProto, error) {
+	msg, ok := p.messages[strings.TrimPrefix(typeName, ".")]
+	if !ok {
+		return nil, fmt.Errorf("message type %s not found in descriptors of the server", strings.TrimPrefix(typeName, "."))
+	}
+	return msg, nil
+}
+
+// dynamicMessage is a message of type known only from its descriptor. It holds the message in wire format
+// and marshals and unmarshals itself, so that it can be sent and received with the default GRPC codec.
+type dynamicMessage struct {
+	data []byte
+}
+
+func (m *dynamicMessage) Reset()         { m.data = nil }
+func (m *dynamicMessage) String() string { return fmt.Sprintf("%x", m.data) }
+func (m *dynamicMessage) ProtoMessage()  {}
+
+func (m *dynamicMessage) Marshal() ([]byte, error) {
+	return m.data, nil
+}
+
+func (m *dynamicMessage) Unmarshal(data []byte) error {
+	m.data = append([]byte(nil), data...)
+	return nil
+}
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// encodeJSON converts message of given type from JSON (as defined by the protobuf JSON mapping) to wire format.
+// Well-known types are handled as regular messages.
+func (p *descriptorPool) encodeJSON(msg *dpb.DescriptorProto, data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return p.encodeMessage(nil, msg, value)
+}
+
+func (p *descriptorPool) encodeMessage(b []byte, msg *dpb.DescriptorProto, value interface{}) ([]byte, error) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected object for message %s, got %v", msg.GetName(), value)
+	}
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := findField(msg, key)
+		if field == nil {
+			return nil, fmt.Errorf("unknown field %q in message %s", key, msg.GetName())
+		}
+		var err error
+		if b, err = p.encodeField(b, field, obj[key]); err != nil {
+			return nil, fmt.Errorf("field %s: %v", key, err)
+		}
+	}
+	return b, nil
+}
+
+func findField(msg *dpb.DescriptorProto, name string) *dpb.FieldDescriptorProto {
+	for _, field := range msg.GetField() {
+		if field.GetName() == name || jsonName(field) == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func (p *descriptorPool) encodeField(b []byte, field *dpb.FieldDescriptorProto, value interface{}) ([]byte, error) {
+	if value == nil {
+		return b, nil
+	}
+	if field.GetLabel() != dpb.FieldDescriptorProto_LABEL_REPEATED {
+		return p.encodeValue(b, field, value)
+	}
+	if entry := p.mapEntry(field); entry != nil {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object, got %v", value)
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyValue, err := mapKeyValue(entry.GetField()[0], key)
+			if err != nil {
+				return nil, err
+			}
+			data, err := p.encodeValue(nil, entry.GetField()[0], keyValue)
+			if err != nil {
+				return nil, err
+			}
+			if data, err = p.encodeField(data, entry.GetField()[1], obj[key]); err != nil {
+				return nil, err
+			}
+			b = appendTag(b, field.GetNumber(), wireBytes)
+			b = appendBytes(b, data)
+		}
+		return b, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected array, got %v", value)
+	}
+	for _, item := range list {
+		var err error
+		if b, err = p.encodeValue(b, field, item); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// mapEntry returns descriptor of the map entry if the field is a map
+func (p *descriptorPool) mapEntry(field *dpb.FieldDescriptorProto) *dpb.DescriptorProto {
+	if field.GetType() != dpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return nil
+	}
+	msg, err := p.message(field.GetTypeName())
+	if err != nil || !msg.GetOptions().GetMapEntry() || len(msg.GetField()) != 2 {
+		return nil
+	}
+	return msg
+}
+
+// mapKeyValue converts JSON object key to the value of the map key field
+func mapKeyValue(field *dpb.FieldDescriptorProto, key string) (interface{}, error) {
+	switch field.GetType() {
+	case dpb.FieldDescriptorProto_TYPE_STRING:
+		return key, nil
+	case dpb.FieldDescriptorProto_TYPE_BOOL:
+		return strconv.ParseBool(key)
+	default:
+		return json.Number(key), nil
+	}
+}
+
+func (p *descriptorPool) encodeValue(b []byte, field *dpb.FieldDescriptorProto, value interface{}) ([]byte, error) {
+	num := field.GetNumber()
+	switch field.GetType() {
+	case dpb.FieldDescriptorProto_TYPE_DOUBLE:
+		v, err := jsonFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return appendFixed64(appendTag(b, num, wireFixed64), math.Float64bits(v)), nil
+	case dpb.FieldDescriptorProto_TYPE_FLOAT:
+		v, err := jsonFloat(value, 32)
+		if err != nil {
+			return nil, err
+		}
+		return appendFixed32(appendTag(b, num, wireFixed32), math.Float32bits(float32(v))), nil
+	case dpb.FieldDescriptorProto_TYPE_INT64, dpb.FieldDescriptorProto_TYPE_INT32:
+		v, err := jsonInt(value, bitSize(field))
+		if err != nil {
+			return nil, err
+		}
+		return appendVarint(appendTag(b, num, wireVarint), uint64(v)), nil
+	case dpb.FieldDescriptorProto_TYPE_SINT64, dpb.FieldDescriptorProto_TYPE_SINT32:
+		v, err := jsonInt(value, bitSize(field))
+		if err != nil {
+			return nil, err
+		}
+		return appendVarint(appendTag(b, num, wireVarint), uint64(v<<1)^uint64(v>>63)), nil
+	case dpb.FieldDescriptorProto_TYPE_UINT64, dpb.FieldDescriptorProto_TYPE_UINT32:
+		v, err := jsonUint(value, bitSize(field))
+		if err != nil {
+			return nil, err
+		}
+		return appendVarint(appendTag(b, num, wireVarint), v), nil
+	case dpb.FieldDescriptorProto_TYPE_FIXED64:
+		v, err := jsonUint(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return appendFixed64(appendTag(b, num, wireFixed64), v), nil
+	case dpb.FieldDescriptorProto_TYPE_SFIXED64:
+		v, err := jsonInt(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return appendFixed64(appendTag(b, num, wireFixed64), uint64(v)), nil
+	case dpb.FieldDescriptorProto_TYPE_FIXED32:
+		v, err := jsonUint(value, 32)
+		if err != nil {
+			return nil, err
+		}
+		return appendFixed32(appendTag(b, num, wireFixed32), uint32(v)), nil
+	case dpb.FieldDescriptorProto_TYPE_SFIXED32:
+		v, err := jsonInt(value, 32)
+		if err != nil {
+			return nil, err
+		}
+		return appendFixed32(appendTag(b, num, wireFixed32), uint32(v)), nil
+	case dpb.FieldDescriptorProto_TYPE_BOOL:
+		v, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool, got %v", value)
+		}
+		var i uint64
+		if v {
+			i = 1
+		}
+		return appendVarint(appendTag(b, num, wireVarint), i), nil
+	case dpb.FieldDescriptorProto_TYPE_ENUM:
+		v, err := p.enumNumber(field, value)
+		if err != nil {
+			return nil, err
+		}
+		return appendVarint(appendTag(b, num, wireVarint), uint64(v)), nil
+	case dpb.FieldDescriptorProto_TYPE_STRING:
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %v", value)
+		}
+		return appendBytes(appendTag(b, num, wireBytes), []byte(v)), nil
+	case dpb.FieldDescriptorProto_TYPE_BYTES:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected base64 string, got %v", value)
+		}
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			if v, err = base64.URLEncoding.DecodeString(s); err != nil {
+				return nil, err
+			}
+		}
+		return appendBytes(appendTag(b, num, wireBytes), v), nil
+	case dpb.FieldDescriptorProto_TYPE_MESSAGE:
+		msg, err := p.message(field.GetTypeName())
+		if err != nil {
+			return nil, err
+		}
+		data, err := p.encodeMessage(nil, msg, value)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(appendTag(b, num, wireBytes), data), nil
+	default:
+		return nil, fmt.Errorf("unsupported field type %v", field.GetType())
+	}
+}
+
+func (p *descriptorPool) enumNumber(field *dpb.FieldDescriptorProto, value interface{}) (int64, error) {
+	name, ok := value.(string)
+	if !ok {
+		return jsonInt(value, 32)
+	}
+	enum, ok := p.enums[strings.TrimPrefix(field.GetTypeName(), ".")]
+	if !ok {
+		return 0, fmt.Errorf("enum type %s not found in descriptors of the server", field.GetTypeName())
+	}
+	for _, v := range enum.GetValue() {
+		if v.GetName() == name {
+			return int64(v.GetNumber()), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown value %q of enum %s", name, enum.GetName())
+}
+
+func bitSize(field *dpb.FieldDescriptorProto) int {
+	switch field.GetType() {
+	case dpb.FieldDescriptorProto_TYPE_INT32, dpb.FieldDescriptorProto_TYPE_SINT32, dpb.FieldDescriptorProto_TYPE_UINT32:
+		return 32
+	}
+	return 64
+}
+
+// jsonInt parses integer given as JSON number or string
+func jsonInt(value interface{}, bits int) (int64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return strconv.ParseInt(string(v), 10, bits)
+	case string:
+		return strconv.ParseInt(v, 10, bits)
+	}
+	return 0, fmt.Errorf("expected integer, got %v", value)
+}
+
+// jsonUint parses unsigned integer given as JSON number or string
+func jsonUint(value interface{}, bits int) (uint64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return strconv.ParseUint(string(v), 10, bits)
+	case string:
+		return strconv.ParseUint(v, 10, bits)
+	}
+	return 0, fmt.Errorf("expected unsigned integer, got %v", value)
+}
+
+// jsonFloat parses floating point number given as JSON number or string (including "NaN" and "Infinity")
+func jsonFloat(value interface{}, bits int) (float64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return strconv.ParseFloat(string(v), bits)
+	case string:
+		switch v {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return strconv.ParseFloat(v, bits)
+	}
+	return 0, fmt.Errorf("expected number, got %v", value)
+}
+
+func appendTag(b []byte, num int32, wireType int) []byte {
+	return appendVarint(b, uint64(num)<<3|uint64(wireType))
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendFixed64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendFixed32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendBytes(b []byte, v []byte) []byte {
+	return append(appendVarint(b, uint64(len(v))), v...)
+}
+
+// wireValue is a single field value read from the wire format
+type wireValue struct {
+	wireType int
+	num      uint64
+	data     []byte
+}
+
+// decodeJSON converts message of given type from wire format to JSON (as defined by the protobuf JSON mapping).
+// Fields are written in order of their declaration, fields not present on the wire and unknown fields are omitted.
+func (p *descriptorPool) decodeJSON(msg *dpb.DescriptorProto, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := p.decodeMessage(&buf, msg, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *descriptorPool) decodeMessage(buf *bytes.Buffer, msg *dpb.DescriptorProto, data []byte) error {
+	values, err := readWireValues(data)
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('{')
+	first := true
+	for _, field := range msg.GetField() {
+		fieldValues := values[field.GetNumber()]
+		if len(fieldValues) == 0 {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		name, _ := json.Marshal(jsonName(field))
+		buf.Write(name)
+		buf.WriteByte(':')
+		if err := p.decodeField(buf, field, fieldValues); err != nil {
+			return fmt.Errorf("field %s: %v", field.GetName(), err)
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (p *descriptorPool) decodeField(buf *bytes.Buffer, field *dpb.FieldDescriptorProto, values []wireValue) error {
+	if field.GetLabel() != dpb.FieldDescriptorProto_LABEL_REPEATED {
+		if field.GetType() == dpb.FieldDescriptorProto_TYPE_MESSAGE {
+			// occurrences of embedded message are merged
+			var data []byte
+			for _, v := range values {
+				data = append(data, v.data...)
+			}
+			return p.decodeValue(buf, field, wireValue{wireType: wireBytes, data: data})
+		}
+		return p.decodeValue(buf, field, values[len(values)-1])
+	}
+	if entry := p.mapEntry(field); entry != nil {
+		buf.WriteByte('{')
+		for i, v := range values {
+			entryValues, err := readWireValues(v.data)
+			if err != nil {
+				return err
+			}
+			var key bytes.Buffer
+			keyField, keyValue := entry.GetField()[0], wireValue{wireType: wireType(entry.GetField()[0])}
+			if keys := entryValues[1]; len(keys) > 0 {
+				keyValue = keys[len(keys)-1]
+			}
+			if err := p.decodeValue(&key, keyField, keyValue); err != nil {
+				return err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if key.Bytes()[0] != '"' {
+				// JSON object keys are always strings
+				name, _ := json.Marshal(key.String())
+				buf.Write(name)
+			} else {
+				buf.Write(key.Bytes())
+			}
+			buf.WriteByte(':')
+			valueField := entry.GetField()[1]
+			if vals := entryValues[2]; len(vals) > 0 {
+				if err := p.decodeField(buf, valueField, vals); err != nil {
+					return err
+				}
+			} else if err := p.decodeValue(buf, valueField, wireValue{wireType: wireType(valueField)}); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+	buf.WriteByte('[')
+	first := true
+	for _, v := range values {
+		items := []wireValue{v}
+		if v.wireType == wireBytes && wireType(field) != wireBytes {
+			// packed repeated scalars
+			var err error
+			if items, err = unpack(v.data, wireType(field)); err != nil {
+				return err
+			}
+		}
+		for _, item := range items {
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			if err := p.decodeValue(buf, field, item); err != nil {
+				return err
+			}
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func (p *descriptorPool) decodeValue(buf *bytes.Buffer, field *dpb.FieldDescriptorProto, value wireValue) error {
+	if value.wireType != wireType(field) {
+		return fmt.Errorf("unexpected wire type %d", value.wireType)
+	}
+	switch field.GetType() {
+	case dpb.FieldDescriptorProto_TYPE_DOUBLE:
+		writeFloat(buf, math.Float64frombits(value.num), 64)
+	case dpb.FieldDescriptorProto_TYPE_FLOAT:
+		writeFloat(buf, float64(math.Float32frombits(uint32(value.num))), 32)
+	case dpb.FieldDescriptorProto_TYPE_INT64, dpb.FieldDescriptorProto_TYPE_SFIXED64:
+		fmt.Fprintf(buf, `"%d"`, int64(value.num))
+	case dpb.FieldDescriptorProto_TYPE_UINT64, dpb.FieldDescriptorProto_TYPE_FIXED64:
+		fmt.Fprintf(buf, `"%d"`, value.num)
+	case dpb.FieldDescriptorProto_TYPE_SINT64:
+		fmt.Fprintf(buf, `"%d"`, int64(value.num>>1)^-int64(value.num&1))
+	case dpb.FieldDescriptorProto_TYPE_INT32, dpb.FieldDescriptorProto_TYPE_SFIXED32:
+		fmt.Fprintf(buf, "%d", int32(value.num))
+	case dpb.FieldDescriptorProto_TYPE_UINT32, dpb.FieldDescriptorProto_TYPE_FIXED32:
+		fmt.Fprintf(buf, "%d", uint32(value.num))
+	case dpb.FieldDescriptorProto_TYPE_SINT32:
+		fmt.Fprintf(buf, "%d", int32(uint32(value.num)>>1)^-int32(value.num&1))
+	case dpb.FieldDescriptorProto_TYPE_BOOL:
+		buf.WriteString(strconv.FormatBool(value.num != 0))
+	case dpb.FieldDescriptorProto_TYPE_ENUM:
+		buf.WriteString(p.enumName(field, int32(value.num)))
+	case dpb.FieldDescriptorProto_TYPE_STRING:
+		s, _ := json.Marshal(string(value.data))
+		buf.Write(s)
+	case dpb.FieldDescriptorProto_TYPE_BYTES:
+		s, _ := json.Marshal(base64.StdEncoding.EncodeToString(value.data))
+		buf.Write(s)
+	case dpb.FieldDescriptorProto_TYPE_MESSAGE:
+		msg, err := p.message(field.GetTypeName())
+		if err != nil {
+			return err
+		}
+		return p.decodeMessage(buf, msg, value.data)
+	default:
+		return fmt.Errorf("unsupported field type %v", field.GetType())
+	}
+	return nil
+}
+
+// enumName returns JSON value of the enum, its name if known or the number otherwise
+func (p *descriptorPool) enumName(field *dpb.FieldDescriptorProto, num int32) string {
+	if enum, ok := p.enums[strings.TrimPrefix(field.GetTypeName(), ".")]; ok {
+		for _, v := range enum.GetValue() {
+			if v.GetNumber() == num {
+				s, _ := json.Marshal(v.GetName())
+				return string(s)
+			}
+		}
+	}
+	return strconv.Itoa(int(num))
+}
+
+func writeFloat(buf *bytes.Buffer, v float64, bits int) {
+	switch {
+	case math.IsNaN(v):
+		buf.WriteString(`"NaN"`)
+	case math.IsInf(v, 1):
+		buf.WriteString(`"Infinity"`)
+	case math.IsInf(v, -1):
+		buf.WriteString(`"-Infinity"`)
+	default:
+		buf.WriteString(strconv.FormatFloat(v, 'g', -1, bits))
+	}
+}
+
+// wireType returns wire type used for values of the field
+func wireType(field *dpb.FieldDescriptorProto) int {
+	switch field.GetType() {
+	case dpb.FieldDescriptorProto_TYPE_DOUBLE, dpb.FieldDescriptorProto_TYPE_FIXED64, dpb.FieldDescriptorProto_TYPE_SFIXED64:
+		return wireFixed64
+	case dpb.FieldDescriptorProto_TYPE_FLOAT, dpb.FieldDescriptorProto_TYPE_FIXED32, dpb.FieldDescriptorProto_TYPE_SFIXED32:
+		return wireFixed32
+	case dpb.FieldDescriptorProto_TYPE_STRING, dpb.FieldDescriptorProto_TYPE_BYTES, dpb.FieldDescriptorProto_TYPE_MESSAGE:
+		return wireBytes
+	}
+	return wireVarint
+}
+
+// readWireValues splits message in wire format into values of its fields
+func readWireValues(data []byte) (map[int32][]wireValue, error) {
+	values := make(map[int32][]wireValue)
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid field tag")
+		}
+		data = data[n:]
+		num, wt := int32(tag>>3), int(tag&7)
+		v, rest, err := readWireValue(data, wt)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+		values[num] = append(values[num], v)
+	}
+	return values, nil
+}
+
+func readWireValue(data []byte, wt int) (wireValue, []byte, error) {
+	v := wireValue{wireType: wt}
+	switch wt {
+	case wireVarint:
+		num, n := binary.Uvarint(data)
+		if n <= 0 {
+			return v, nil, fmt.Errorf("invalid varint")
+		}
+		v.num = num
+		return v, data[n:], nil
+	case wireFixed64:
+		if len(data) < 8 {
+			return v, nil, fmt.Errorf("unexpected end of data")
+		}
+		v.num = binary.LittleEndian.Uint64(data)
+		return v, data[8:], nil
+	case wireFixed32:
+		if len(data) < 4 {
+			return v, nil, fmt.Errorf("unexpected end of data")
+		}
+		v.num = uint64(binary.LittleEndian.Uint32(data))
+		return v, data[4:], nil
+	case wireBytes:
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > uint64(len(data)-n) {
+			return v, nil, fmt.Errorf("invalid length of field")
+		}
+		v.data = data[n : n+int(size)]
+		return v, data[n+int(size):], nil
+	}
+	return v, nil, fmt.Errorf("unsupported wire type %d", wt)
+}
+
+// unpack splits packed repeated scalar values
+func unpack(data []byte, wt int) ([]wireValue, error) {
+	var values []wireValue
+	for len(data) > 0 {
+		v, rest, err := readWireValue(data, wt)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		data = rest
+	}
+	return values, nil
+}
+
+// jsonName returns name of the field used in JSON, lowerCamelCase of the field name if not defined
+func jsonName(field *dpb.FieldDescriptorProto) string {
+	if name := field.GetJsonName(); name != "" {
+		return name
+	}
+	var b strings.Builder
+	upper := false
+	for _, c := range field.GetName() {
+		switch {
+		case c == '_':
+			upper = true
+		case upper && 'a' <= c && c <= 'z':
+			b.WriteRune(c - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteRune(c)
+			upper = false
+		}
+	}
+	return b.String()
+}
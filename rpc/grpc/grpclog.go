@@ -0,0 +1,61 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/grpclog"
+
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// loggerV2 adapts logging.Logger to grpclog.LoggerV2
+type loggerV2 struct {
+	logging.Logger
+}
+
+// NewLoggerV2 returns grpclog.LoggerV2 logging with the given logger. The gRPC verbosity
+// is checked against the logger verbosity (see logging.VerboseLogger), loggers without verbosity
+// enable only the trace level transport logging.
+func NewLoggerV2(log logging.Logger) grpclog.LoggerV2 {
+	return &loggerV2{Logger: log}
+}
+
+// V reports whether the logger verbosity is at least at the requested level. The trace level
+// enables gRPC transport logging (verbosity 2) regardless of the logger verbosity.
+func (l *loggerV2) V(level int) bool {
+	if level <= logLevel && logging.IsLevelEnabled(l.Logger, logging.TraceLevel) {
+		return true
+	}
+	if vl, ok := l.Logger.(logging.VerboseLogger); ok {
+		return level <= vl.GetVerbosity()
+	}
+	return false
+}
+
+var grpcLogOnce sync.Once
+
+// SetupGrpcLogging makes gRPC internals (e.g. transport warnings about failed TLS handshakes) log
+// with the given logger instead of the default gRPC logger. The setting is global for the whole
+// process and must be done before any other gRPC function is called, thus only the first call
+// takes effect and subsequent calls are ignored. It returns true if the logger was installed.
+func SetupGrpcLogging(log logging.Logger) (installed bool) {
+	grpcLogOnce.Do(func() {
+		grpclog.SetLoggerV2(NewLoggerV2(log))
+		installed = true
+	})
+	return installed
+}
@@ -0,0 +1,48 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.ligato.io/cn-infra/v2/logging"
+	"go.ligato.io/cn-infra/v2/logging/logrus"
+)
+
+func TestLoggerV2(t *testing.T) {
+	log := logrus.NewLogger("grpclog-test")
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	grpcLog := NewLoggerV2(log)
+
+	grpcLog.Warningf("handshake failed: %v", "tls: bad certificate")
+	if !strings.Contains(buffer.String(), "handshake failed: tls: bad certificate") {
+		t.Errorf("warning not logged: %q", buffer.String())
+	}
+
+	if grpcLog.V(1) {
+		t.Errorf("verbosity 1 should not be enabled")
+	}
+	log.SetVerbosity(1)
+	if !grpcLog.V(1) || grpcLog.V(2) {
+		t.Errorf("expected verbosity 1 to be enabled, 2 disabled")
+	}
+	log.SetLevel(logging.TraceLevel)
+	if !grpcLog.V(2) {
+		t.Errorf("trace level should enable transport logging")
+	}
+}
@@ -0,0 +1,106 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// Package grpctest provides an in-process GRPC server and client pair for integration
+// tests of services and the interceptor stack of the GRPC plugin, without binding ports.
+package grpctest
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/test/bufconn"
+
+	cngrpc "go.ligato.io/cn-infra/v2/rpc/grpc"
+)
+
+const bufSize = 1024 * 1024
+
+// Harness is a GRPC plugin serving on an in-memory listener with a connected client.
+type Harness struct {
+	// Plugin is the initialized GRPC plugin
+	Plugin *cngrpc.Plugin
+	// Conn is the client connection to the plugin server
+	Conn *grpc.ClientConn
+
+	lis *bufconn.Listener
+}
+
+// New initializes the GRPC plugin with given options (e.g. UseAuth, UseRecovery) serving on
+// an in-memory listener, registers services using the register callback and connects a client.
+// Call Close to tear the harness down.
+func New(register func(*grpc.Server), opts ...cngrpc.Option) (*Harness, error) {
+	lis := bufconn.Listen(bufSize)
+	p := cngrpc.NewPlugin(append(opts, cngrpc.UseListener(lis))...)
+	if err := p.Init(); err != nil {
+		return nil, err
+	}
+	if register != nil {
+		register(p.GetServer())
+	}
+	if err := p.AfterInit(); err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	h := &Harness{Plugin: p, lis: lis}
+	conn, err := h.Dial()
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	h.Conn = conn
+	return h, nil
+}
+
+// Dial creates a new client connection to the harness server, e.g. with different
+// per-RPC credentials. The caller is responsible for closing it.
+func (h *Harness) Dial(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts = append([]grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return h.lis.Dial()
+		}),
+	}, opts...)
+	return grpc.DialContext(ctx, "bufnet", opts...)
+}
+
+// UseCredentials reconnects the harness client with per-RPC credentials attached to every call (e.g.
+// cngrpc.BearerTokenCredentials), so that the calls pass through the auth interceptors of the plugin
+// the same way as calls of external clients.
+func (h *Harness) UseCredentials(creds credentials.PerRPCCredentials) error {
+	conn, err := h.Dial(grpc.WithPerRPCCredentials(creds))
+	if err != nil {
+		return err
+	}
+	if h.Conn != nil {
+		h.Conn.Close()
+	}
+	h.Conn = conn
+	return nil
+}
+
+// Close closes the client connection and stops the server.
+func (h *Harness) Close() error {
+	if h.Conn != nil {
+		h.Conn.Close()
+	}
+	return h.Plugin.Close()
+}
@@ -0,0 +1,88 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpctest_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	cngrpc "go.ligato.io/cn-infra/v2/rpc/grpc"
+	"go.ligato.io/cn-infra/v2/rpc/grpc/grpctest"
+)
+
+type panickingHealth struct {
+	*health.Server
+}
+
+func (panickingHealth) Check(context.Context, *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	panic("check failure")
+}
+
+func TestHarness(t *testing.T) {
+	h, err := grpctest.New(func(srv *grpc.Server) {
+		healthpb.RegisterHealthServer(srv, panickingHealth{health.NewServer()})
+	}, cngrpc.UseRecovery())
+	if err != nil {
+		t.Fatalf("harness setup failed: %v", err)
+	}
+	defer h.Close()
+
+	// the panic is handled by the recovery interceptor of the plugin
+	_, err = healthpb.NewHealthClient(h.Conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("expected Internal error, got %v", err)
+	}
+}
+
+func TestHarnessCredentials(t *testing.T) {
+	h, err := grpctest.New(func(srv *grpc.Server) {
+		healthpb.RegisterHealthServer(srv, health.NewServer())
+	}, cngrpc.UseAuth(&cngrpc.Authenticator{Username: "admin", Password: "secret", Token: "token"}))
+	if err != nil {
+		t.Fatalf("harness setup failed: %v", err)
+	}
+	defer h.Close()
+
+	check := func() error {
+		_, err := healthpb.NewHealthClient(h.Conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+		return err
+	}
+	if err := check(); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated error without credentials, got %v", err)
+	}
+	for _, creds := range []cngrpc.MetadataCredentials{
+		cngrpc.BearerTokenCredentials("token"),
+		cngrpc.BasicAuthCredentials("admin", "secret"),
+	} {
+		if err := h.UseCredentials(creds); err != nil {
+			t.Fatalf("reconnect failed: %v", err)
+		}
+		if err := check(); err != nil {
+			t.Errorf("call with credentials %v failed: %v", creds, err)
+		}
+	}
+	if err := h.UseCredentials(cngrpc.BearerTokenCredentials("invalid")); err != nil {
+		t.Fatalf("reconnect failed: %v", err)
+	}
+	if err := check(); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated error with invalid token, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// IdempotencyKeyHeader is the metadata key carrying client-provided idempotency key.
+const IdempotencyKeyHeader = "idempotency-key"
+
+// IdempotencyCache stores encoded responses of already handled requests.
+// Values are opaque byte slices, so the cache can be backed by any
+// key-value store (in-memory LRU, Redis, ...).
+type IdempotencyCache interface {
+	// Get returns value stored under the key, if it exists and is not expired.
+	Get(key string) ([]byte, bool)
+	// Set stores value under the key for the given TTL.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// UnaryServerInterceptorIdempotency returns a new unary server interceptor that deduplicates requests
+// carrying the same idempotency key (see IdempotencyKeyHeader). The key is scoped to the method, the caller
+// (user authenticated by Authenticator, or subject of the verified client certificate) and the request
+// content, so different callers or different requests reusing the same key never share a response.
+// Successful response of the first request is stored in the cache and returned for every repeated request
+// within the TTL, without executing the handler again. Duplicates arriving while the first request is still
+// being handled wait for its result. Requests without the key are passed to the handler directly.
+func UnaryServerInterceptorIdempotency(cache IdempotencyCache, ttl time.Duration) grpc.UnaryServerInterceptor {
+	var (
+		mu       sync.Mutex
+		inFlight = make(map[string]*idempotentCall)
+	)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		idemKey, ok := idempotencyKey(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+		cacheKey, err := idempotencyCacheKey(ctx, info.FullMethod, idemKey, req)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to compute idempotency key: %v", err)
+		}
+
+		if cached, found := cache.Get(cacheKey); found {
+			resp, err := decodeResponse(cached)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to decode cached response: %v", err)
+			}
+			return resp, nil
+		}
+
+		// wait for the same request which is being handled
+		mu.Lock()
+		if call, ok := inFlight[cacheKey]; ok {
+			mu.Unlock()
+			select {
+			case <-call.done:
+				return call.resp, call.err
+			case <-ctx.Done():
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+		}
+		call := &idempotentCall{done: make(chan struct{})}
+		inFlight[cacheKey] = call
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			delete(inFlight, cacheKey)
+			mu.Unlock()
+			close(call.done)
+		}()
+
+		call.resp, call.err = handler(ctx, req)
+		if call.err != nil {
+			return call.resp, call.err
+		}
+		if msg, ok := call.resp.(proto.Message); ok {
+			if data, err := encodeResponse(msg); err == nil {
+				cache.Set(cacheKey, data, ttl)
+			}
+		}
+		return call.resp, nil
+	}
+}
+
+// idempotentCall is a request being handled, its result is shared with duplicates
+type idempotentCall struct {
+	done chan struct{}
+	resp interface{}
+	err  error
+}
+
+// idempotencyCacheKey returns the cache key for the request with given idempotency key
+func idempotencyCacheKey(ctx context.Context, fullMethod, idemKey string, req interface{}) (string, error) {
+	h := sha256.New()
+	for _, part := range []string{idempotencyCaller(ctx), idemKey} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	if msg, ok := req.(proto.Message); ok {
+		buf := proto.NewBuffer(nil)
+		buf.SetDeterministic(true)
+		if err := buf.Marshal(msg); err != nil {
+			return "", err
+		}
+		h.Write(buf.Bytes())
+	}
+	return fullMethod + "/" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// idempotencyCaller identifies the caller by the authenticated user or the verified client certificate,
+// it returns empty string for anonymous callers
+func idempotencyCaller(ctx context.Context) string {
+	if user, ok := GetUserMetadata(ctx); ok && user.ID != "" {
+		return "user:" + user.ID
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok &&
+			len(tlsInfo.State.VerifiedChains) > 0 && len(tlsInfo.State.VerifiedChains[0]) > 0 {
+			return "cert:" + tlsInfo.State.VerifiedChains[0][0].Subject.String()
+		}
+	}
+	return ""
+}
+
+func idempotencyKey(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	keys := md.Get(IdempotencyKeyHeader)
+	if len(keys) == 0 || keys[0] == "" {
+		return "", false
+	}
+	return keys[0], true
+}
+
+// encodeResponse stores message type name together with the message so it can be decoded
+// without knowing the response type in advance.
+func encodeResponse(msg proto.Message) ([]byte, error) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(proto.MessageName(msg))
+	buf.WriteByte(0)
+	buf.Write(data)
+	return buf.Bytes(), nil
+}
+
+func decodeResponse(data []byte) (proto.Message, error) {
+	idx := bytes.IndexByte(data, 0)
+	if idx < 0 {
+		return nil, status.Error(codes.Internal, "malformed cached response")
+	}
+	msgType := proto.MessageType(string(data[:idx]))
+	if msgType == nil {
+		return nil, status.Errorf(codes.Internal, "unknown message type %q", data[:idx])
+	}
+	msg := reflect.New(msgType.Elem()).Interface().(proto.Message)
+	if err := proto.Unmarshal(data[idx+1:], msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// MemoryIdempotencyCache is an in-memory LRU implementation of IdempotencyCache.
+type MemoryIdempotencyCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type idempotencyEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryIdempotencyCache returns in-memory cache holding at most size entries.
+func NewMemoryIdempotencyCache(size int) *MemoryIdempotencyCache {
+	return &MemoryIdempotencyCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Get returns value stored under the key, if it exists and is not expired.
+func (c *MemoryIdempotencyCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*idempotencyEntry)
+	if time.Now().After(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set stores value under the key for the given TTL. The least recently used
+// entry is evicted if the cache is full.
+func (c *MemoryIdempotencyCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*idempotencyEntry)
+		entry.value, entry.expires = value, time.Now().Add(ttl)
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&idempotencyEntry{
+		key:     key,
+		value:   value,
+		expires: time.Now().Add(ttl),
+	})
+	for c.size > 0 && c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*idempotencyEntry).key)
+	}
+}
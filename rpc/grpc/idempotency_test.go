@@ -0,0 +1,176 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestIdempotencyInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptorIdempotency(NewMemoryIdempotencyCache(10), time.Minute)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Create"}
+
+	var calls int
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return &wrappers.StringValue{Value: "created"}, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyHeader, "key1"))
+	for i := 0; i < 3; i++ {
+		resp, err := interceptor(ctx, nil, info, handler)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !proto.Equal(resp.(proto.Message), &wrappers.StringValue{Value: "created"}) {
+			t.Fatalf("unexpected response: %v", resp)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected handler to be called once, got %d calls", calls)
+	}
+
+	// requests without key are never deduplicated
+	for i := 0; i < 2; i++ {
+		if _, err := interceptor(context.Background(), nil, info, handler); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("expected handler to be called 3 times, got %d calls", calls)
+	}
+}
+
+func TestIdempotencyKeyScope(t *testing.T) {
+	interceptor := UnaryServerInterceptorIdempotency(NewMemoryIdempotencyCache(10), time.Minute)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Create"}
+
+	var calls int
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return &wrappers.StringValue{Value: req.(*wrappers.StringValue).Value}, nil
+	}
+	call := func(user string, req string) string {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyHeader, "key1"))
+		ctx = context.WithValue(ctx, userMDKey{}, &UserMetadata{ID: user})
+		resp, err := interceptor(ctx, &wrappers.StringValue{Value: req}, info, handler)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.(*wrappers.StringValue).Value
+	}
+
+	if resp := call("alice", "first"); resp != "first" {
+		t.Errorf("unexpected response: %q", resp)
+	}
+	// other caller reusing the key does not get response of alice
+	if resp := call("bob", "second"); resp != "second" {
+		t.Errorf("unexpected response: %q", resp)
+	}
+	// different request with the same key is not deduplicated
+	if resp := call("alice", "third"); resp != "third" {
+		t.Errorf("unexpected response: %q", resp)
+	}
+	if resp := call("alice", "first"); resp != "first" {
+		t.Errorf("unexpected response: %q", resp)
+	}
+	if calls != 3 {
+		t.Errorf("expected handler to be called 3 times, got %d calls", calls)
+	}
+}
+
+func TestIdempotencyConcurrentDuplicates(t *testing.T) {
+	interceptor := UnaryServerInterceptorIdempotency(NewMemoryIdempotencyCache(10), time.Minute)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Create"}
+
+	var calls int32
+	release := make(chan struct{})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &wrappers.StringValue{Value: "created"}, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyHeader, "key1"))
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := interceptor(ctx, &wrappers.StringValue{Value: "req"}, info, handler)
+			if err == nil && !proto.Equal(resp.(proto.Message), &wrappers.StringValue{Value: "created"}) {
+				err = fmt.Errorf("unexpected response: %v", resp)
+			}
+			errs <- err
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("expected handler to be called once, got %d calls", n)
+	}
+
+	// waiting duplicate gives up when its context is done
+	release = make(chan struct{})
+	defer close(release)
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyHeader, "key2"))
+	go interceptor(ctx, nil, info, handler)
+	time.Sleep(50 * time.Millisecond)
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := interceptor(waitCtx, nil, info, handler); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestMemoryIdempotencyCache(t *testing.T) {
+	cache := NewMemoryIdempotencyCache(2)
+	cache.Set("a", []byte("a"), time.Minute)
+	cache.Set("b", []byte("b"), time.Minute)
+	cache.Get("a")
+	cache.Set("c", []byte("c"), time.Minute)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected least recently used entry to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Errorf("expected entry a to be cached")
+	}
+
+	cache.Set("d", []byte("d"), -time.Second)
+	if _, ok := cache.Get("d"); ok {
+		t.Errorf("expected expired entry to be ignored")
+	}
+}
@@ -0,0 +1,122 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// RequestIDHeader is the metadata key carrying the request ID reported by InFlightRequests.
+const RequestIDHeader = "x-request-id"
+
+// RequestInfo describes a single RPC which is currently being executed.
+type RequestInfo struct {
+	Method    string    `json:"method"`
+	StartTime time.Time `json:"start_time"`
+	Peer      string    `json:"peer,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Stream    bool      `json:"stream,omitempty"`
+}
+
+// InFlightTracker keeps track of RPCs which are currently being executed.
+// Calls are removed from the tracker also when the handler panics.
+type InFlightTracker struct {
+	mu       sync.Mutex
+	nextID   uint64
+	requests map[uint64]RequestInfo
+}
+
+// NewInFlightTracker returns a new empty tracker.
+func NewInFlightTracker() *InFlightTracker {
+	return &InFlightTracker{
+		requests: make(map[uint64]RequestInfo),
+	}
+}
+
+// Requests returns currently executing RPCs ordered by their start time.
+func (t *InFlightTracker) Requests() []RequestInfo {
+	t.mu.Lock()
+	list := make([]RequestInfo, 0, len(t.requests))
+	for _, req := range t.requests {
+		list = append(list, req)
+	}
+	t.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartTime.Before(list[j].StartTime)
+	})
+	return list
+}
+
+func (t *InFlightTracker) add(ctx context.Context, method string, stream bool) uint64 {
+	info := RequestInfo{
+		Method:    method,
+		StartTime: time.Now(),
+		Stream:    stream,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.Peer = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(RequestIDHeader); len(ids) > 0 {
+			info.RequestID = ids[0]
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	t.requests[t.nextID] = info
+	return t.nextID
+}
+
+func (t *InFlightTracker) remove(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.requests, id)
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor that registers calls in the tracker.
+func (t *InFlightTracker) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		defer t.remove(t.add(ctx, info.FullMethod, false))
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that registers streams in the tracker.
+func (t *InFlightTracker) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		defer t.remove(t.add(stream.Context(), info.FullMethod, true))
+
+		return handler(srv, stream)
+	}
+}
+
+// InFlightRequests returns RPCs currently being executed by the server. It returns nil
+// unless in-flight tracking is enabled with UseInFlightTracking option.
+func (p *Plugin) InFlightRequests() []RequestInfo {
+	if p.inFlight == nil {
+		return nil
+	}
+	return p.inFlight.Requests()
+}
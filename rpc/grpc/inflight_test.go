@@ -0,0 +1,56 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestInFlightTracker(t *testing.T) {
+	tracker := NewInFlightTracker()
+	interceptor := tracker.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Hang"}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "req1"))
+	var inFlight []RequestInfo
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		inFlight = tracker.Requests()
+		return nil, nil
+	}
+	if _, err := interceptor(ctx, nil, info, handler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(inFlight) != 1 || inFlight[0].Method != info.FullMethod || inFlight[0].RequestID != "req1" {
+		t.Errorf("unexpected in-flight requests during call: %+v", inFlight)
+	}
+	if n := len(tracker.Requests()); n != 0 {
+		t.Errorf("expected no in-flight requests after call, got %d", n)
+	}
+
+	// panicking handler must not leak the entry
+	func() {
+		defer func() { recover() }()
+		interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("handler failure")
+		})
+	}()
+	if n := len(tracker.Requests()); n != 0 {
+		t.Errorf("expected no in-flight requests after panic, got %d", n)
+	}
+}
@@ -28,6 +28,22 @@ import (
 
 // ListenAndServe starts configured listener and serving for clients
 func ListenAndServe(cfg *Config, srv *grpc.Server) (netListener net.Listener, err error) {
+	netListener, err = listen(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		err := srv.Serve(netListener)
+		// Serve always returns non-nil error
+		logging.Debugf("GRPC server Serve: %v", err)
+	}()
+
+	return netListener, nil
+}
+
+// listen starts configured listener
+func listen(cfg *Config) (netListener net.Listener, err error) {
 	switch socketType := cfg.getSocketType(); socketType {
 	case "unix", "unixpacket":
 		permissions, err := getUnixSocketFilePermissions(cfg.Permission)
@@ -53,13 +69,6 @@ func ListenAndServe(cfg *Config, srv *grpc.Server) (netListener net.Listener, er
 			return nil, err
 		}
 	}
-
-	go func() {
-		err := srv.Serve(netListener)
-		// Serve always returns non-nil error
-		logging.Debugf("GRPC server Serve: %v", err)
-	}()
-
 	return netListener, nil
 }
 
@@ -0,0 +1,122 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"go.ligato.io/cn-infra/v2/logging"
+	"go.ligato.io/cn-infra/v2/utils/redact"
+)
+
+// Masker returns copy of the message with sensitive fields masked.
+type Masker func(msg proto.Message) proto.Message
+
+var (
+	maskersMu sync.RWMutex
+	maskers   = make(map[string]Masker)
+)
+
+// RegisterMasker registers masker for messages of the same type as msg. Registered
+// masker is used by MaskMessage instead of the default redaction.
+func RegisterMasker(msg proto.Message, masker Masker) {
+	maskersMu.Lock()
+	defer maskersMu.Unlock()
+	maskers[proto.MessageName(msg)] = masker
+}
+
+// MaskFields returns Masker which masks top-level fields with given proto names.
+// String fields are replaced with redacted string, other fields are cleared.
+func MaskFields(names ...string) Masker {
+	masked := make(map[string]bool, len(names))
+	for _, name := range names {
+		masked[name] = true
+	}
+	return func(msg proto.Message) proto.Message {
+		msgCopy := proto.Clone(msg)
+		val := reflect.ValueOf(msgCopy)
+		if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+			return msgCopy
+		}
+		props := proto.GetProperties(val.Elem().Type())
+		for i, prop := range props.Prop {
+			if !masked[prop.OrigName] {
+				continue
+			}
+			field := val.Elem().Field(i)
+			if field.Kind() == reflect.String {
+				field.SetString(redact.String(field.String()))
+			} else {
+				field.Set(reflect.Zero(field.Type()))
+			}
+		}
+		return msgCopy
+	}
+}
+
+// MaskMessage returns value suitable for logging with sensitive fields masked.
+// Registered masker for the message type is preferred, otherwise fields
+// implementing redact.Redactor are redacted.
+func MaskMessage(v interface{}) interface{} {
+	msg, ok := v.(proto.Message)
+	if !ok || reflect.ValueOf(msg).IsNil() {
+		return redact.Value(v)
+	}
+	maskersMu.RLock()
+	masker, ok := maskers[proto.MessageName(msg)]
+	maskersMu.RUnlock()
+	if ok {
+		return masker(msg)
+	}
+	return redact.Value(msg)
+}
+
+// UnaryServerInterceptorPayloadLogging returns a new unary server interceptor that logs request and
+// response payloads at debug level. Sensitive fields are masked using MaskMessage.
+func UnaryServerInterceptorPayloadLogging(log logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if log.GetLevel() < logging.DebugLevel {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		fields := logging.Fields{
+			"method":   info.FullMethod,
+			"duration": time.Since(start).String(),
+			"code":     status.Code(err).String(),
+			"request":  MaskMessage(req),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+			if ctx.Err() != nil {
+				// call cancelled by the client or its deadline exceeded
+				fields["cancelled"] = true
+			}
+		} else {
+			fields["response"] = MaskMessage(resp)
+		}
+		log.WithFields(fields).Debug("gRPC call")
+
+		return resp, err
+	}
+}
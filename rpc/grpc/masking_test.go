@@ -0,0 +1,43 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+)
+
+func TestMaskMessage(t *testing.T) {
+	RegisterMasker(&wrappers.StringValue{}, MaskFields("value"))
+
+	msg := &wrappers.StringValue{Value: "secret"}
+	masked, ok := MaskMessage(msg).(*wrappers.StringValue)
+	if !ok {
+		t.Fatalf("unexpected masked type: %T", MaskMessage(msg))
+	}
+	if masked.Value == "secret" {
+		t.Errorf("expected value to be masked")
+	}
+	if msg.Value != "secret" {
+		t.Errorf("expected original message to be unchanged, got %q", msg.Value)
+	}
+
+	// messages without registered masker are left to default redaction
+	other := &wrappers.Int64Value{Value: 42}
+	if v, ok := MaskMessage(other).(*wrappers.Int64Value); !ok || v.Value != 42 {
+		t.Errorf("unexpected result for message without masker: %v", MaskMessage(other))
+	}
+}
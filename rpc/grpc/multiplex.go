@@ -0,0 +1,65 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+// MultiplexHandler returns a handler routing gRPC requests (HTTP/2 with application/grpc content type)
+// to the gRPC server and all other requests to the HTTP handler.
+func MultiplexHandler(srv *grpc.Server, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			srv.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveMultiplexed serves gRPC together with the HTTP handler (see UseHTTPHandler) on the listener. With TLS,
+// HTTP/2 is negotiated with ALPN, otherwise cleartext HTTP/2 (h2c) is accepted next to HTTP/1.1.
+func (p *Plugin) serveMultiplexed(lis net.Listener) error {
+	handler := MultiplexHandler(p.grpcServer, p.httpHandler)
+	h2 := &http2.Server{}
+	srv := &http.Server{}
+	if p.tlsConfig != nil {
+		tc := p.tlsConfig.Clone()
+		tc.NextProtos = []string{http2.NextProtoTLS, "http/1.1"}
+		srv.TLSConfig = tc
+		srv.Handler = handler
+		if err := http2.ConfigureServer(srv, h2); err != nil {
+			return err
+		}
+		lis = tls.NewListener(lis, tc)
+	} else {
+		srv.Handler = h2c.NewHandler(handler, h2)
+	}
+	p.httpServer = srv
+	go func() {
+		err := srv.Serve(lis)
+		// Serve always returns non-nil error
+		p.Log.Debugf("GRPC multiplexed HTTP server Serve: %v", err)
+	}()
+	return nil
+}
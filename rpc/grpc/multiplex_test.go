@@ -0,0 +1,111 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	for _, secure := range []bool{false, true} {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen failed: %v", err)
+		}
+		opts := []Option{UseListener(lis), UseHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello " + r.Proto))
+		}))}
+		dialOpt := grpc.WithInsecure()
+		httpClient := &http.Client{}
+		url := "http://" + lis.Addr().String()
+		if secure {
+			clientTLS := &tls.Config{InsecureSkipVerify: true}
+			opts = append(opts, UseTLS(&tls.Config{Certificates: []tls.Certificate{selfSignedCert(t)}}))
+			dialOpt = grpc.WithTransportCredentials(credentials.NewTLS(clientTLS))
+			httpClient.Transport = &http.Transport{TLSClientConfig: clientTLS}
+			url = "https://" + lis.Addr().String()
+		}
+
+		p := NewPlugin(opts...)
+		if err := p.Init(); err != nil {
+			t.Fatalf("init failed: %v", err)
+		}
+		healthpb.RegisterHealthServer(p.GetServer(), health.NewServer())
+		if err := p.AfterInit(); err != nil {
+			t.Fatalf("after init failed: %v", err)
+		}
+
+		conn, err := grpc.Dial(lis.Addr().String(), dialOpt)
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if _, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Errorf("health check (TLS: %v) failed: %v", secure, err)
+		}
+		cancel()
+		conn.Close()
+
+		resp, err := httpClient.Get(url)
+		if err != nil {
+			t.Fatalf("HTTP request (TLS: %v) failed: %v", secure, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "hello HTTP/1.1" {
+			t.Errorf("unexpected HTTP response (TLS: %v): %q", secure, body)
+		}
+
+		if err := p.Close(); err != nil {
+			t.Errorf("close failed: %v", err)
+		}
+	}
+}
+
+func selfSignedCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key generation failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("certificate creation failed: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
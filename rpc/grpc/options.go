@@ -17,10 +17,14 @@ package grpc
 import (
 	"crypto/tls"
 	"fmt"
+	"net"
+	"net/http"
+	"time"
 
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
 
 	"go.ligato.io/cn-infra/v2/config"
 	"go.ligato.io/cn-infra/v2/logging"
@@ -113,3 +117,168 @@ func UseRateLimiter(r *rate.Limiter) Option {
 		p.limiter = r
 	}
 }
+
+// UseIdempotency returns an Option which enables deduplication of unary requests
+// by idempotency key using the provided cache.
+func UseIdempotency(cache IdempotencyCache, ttl time.Duration) Option {
+	return func(p *Plugin) {
+		p.idempotencyCache = cache
+		p.idempotencyTTL = ttl
+	}
+}
+
+// UseStreamMaxLifetime returns an Option which limits duration of server streaming calls.
+func UseStreamMaxLifetime(lifetime StreamLifetime) Option {
+	return func(p *Plugin) {
+		p.streamLifetime = &lifetime
+	}
+}
+
+// UsePayloadLogging returns an Option which enables logging of request and response
+// payloads at debug level. Sensitive fields are masked (see RegisterMasker).
+func UsePayloadLogging() Option {
+	return func(p *Plugin) {
+		p.payloadLogging = true
+	}
+}
+
+// UseInFlightTracking returns an Option which enables tracking of RPCs currently being executed,
+// see Plugin.InFlightRequests. If HTTP is available, the list is also exposed on /service/inflight.
+func UseInFlightTracking() Option {
+	return func(p *Plugin) {
+		p.inFlight = NewInFlightTracker()
+	}
+}
+
+// UseReflection returns an Option which registers the server reflection service, allowing tools
+// (and DynamicClient) to discover services and methods at runtime.
+func UseReflection() Option {
+	return func(p *Plugin) {
+		p.reflection = true
+	}
+}
+
+// UseDeferredServe returns an Option which defers serving GRPC until Plugin.Start is called,
+// instead of starting it in AfterInit. Use it when some services are registered by plugins
+// initialized after the GRPC plugin.
+func UseDeferredServe() Option {
+	return func(p *Plugin) {
+		p.deferServe = true
+	}
+}
+
+// UseBodyReadRate returns an Option which aborts unary RPCs whose request is not received within
+// the receive timeout given by the rate (see BodyReadRate). It uses the server stats handler, thus it
+// cannot be combined with a custom stats handler set with UseServerOpts.
+func UseBodyReadRate(rate BodyReadRate) Option {
+	return func(p *Plugin) {
+		p.bodyReadRate = &rate
+	}
+}
+
+// UseVersionTrailer returns an Option which attaches the server version (and optionally build time
+// and host name) to the trailer of every response, see ServerVersion.
+func UseVersionTrailer(v ServerVersion) Option {
+	return func(p *Plugin) {
+		p.version = &v
+	}
+}
+
+// UseListener returns an Option which makes the server serve on the given listener (e.g. bufconn listener
+// in tests, or a listener wrapper handling proxy protocol) instead of binding the configured endpoint.
+// The plugin is enabled even if no config file is found.
+func UseListener(lis net.Listener) Option {
+	return func(p *Plugin) {
+		p.listener = lis
+	}
+}
+
+// UseAPIVersion returns an Option which rejects calls declaring (in the api-version metadata)
+// an API version out of the supported range, see APIVersionRange.
+func UseAPIVersion(r APIVersionRange) Option {
+	return func(p *Plugin) {
+		p.apiVersion = &r
+	}
+}
+
+// UseRecovery returns an Option which recovers from panics in service handlers. The panic is logged
+// with its stack (see logging.LogPanic) and the call fails with Internal status.
+func UseRecovery() Option {
+	return func(p *Plugin) {
+		p.recovery = true
+	}
+}
+
+// UseDrain returns an Option which enables graceful drain of the server with Plugin.Drain. The health
+// server is used to report the server as not serving during the drain. If it is nil, a new health server
+// is created and registered to the GRPC server. The delay gives load balancers time to observe the health
+// status before new calls start to be rejected.
+func UseDrain(hs *health.Server, delay time.Duration) Option {
+	return func(p *Plugin) {
+		p.drain = NewDrainCoordinator(hs, delay)
+		if hs == nil {
+			p.drain.health = health.NewServer()
+			p.drain.registerHealth = true
+		}
+	}
+}
+
+// UseGrpcLogging returns an Option which makes gRPC internals log with the grpc-server logger
+// (see SetupGrpcLogging). It is enabled also by the extended-logging config option. Since gRPC
+// logging is global for the process, only the first plugin instance installs its logger.
+func UseGrpcLogging() Option {
+	return func(p *Plugin) {
+		p.grpcLogging = true
+	}
+}
+
+// UseClientCertRequired returns an Option which requires a verified client certificate for given methods
+// (see ClientCertMethods), even if the TLS config does not require it for all clients.
+func UseClientCertRequired(methods ...string) Option {
+	return func(p *Plugin) {
+		p.certMethods = append(p.certMethods, methods...)
+	}
+}
+
+// UseConnByteLimit returns an Option which closes connections after they received more than limit bytes
+// in total, see ConnByteLimiter. Received byte counts are available with Plugin.ConnectionBytes.
+func UseConnByteLimit(limit int64) Option {
+	return func(p *Plugin) {
+		p.connLimiter = NewConnByteLimiter(limit, nil)
+	}
+}
+
+// UseCancellationStatus returns an Option which reports calls failed due to client cancellation or deadline
+// with Canceled or DeadlineExceeded status, so that they are not counted as server errors in metrics and logs.
+func UseCancellationStatus() Option {
+	return func(p *Plugin) {
+		p.cancelStatus = true
+	}
+}
+
+// UseHTTPHandler returns an Option which serves the HTTP handler (e.g. a small REST or health API) on the same
+// port as gRPC. Requests are routed by protocol and content type (see MultiplexHandler), HTTP/2 is negotiated
+// with ALPN if TLS is configured, otherwise cleartext HTTP/2 (h2c) is served. The TLS is then terminated
+// by the HTTP server, so custom transport credentials set by server options cannot be used.
+func UseHTTPHandler(handler http.Handler) Option {
+	return func(p *Plugin) {
+		p.httpHandler = handler
+	}
+}
+
+// UseConcurrencyLimit returns an Option which limits the number of concurrently handled calls, with excess
+// calls queued for their turn, see ConcurrencyLimiter. The current load is available with Plugin.Concurrency
+// and, if HTTP is available, on /service/concurrency. The maxConcurrent must be positive and maxQueue
+// and maxWait must not be negative, otherwise Init fails.
+func UseConcurrencyLimit(maxConcurrent, maxQueue int, maxWait time.Duration) Option {
+	return func(p *Plugin) {
+		if maxConcurrent <= 0 || maxQueue < 0 || maxWait < 0 {
+			p.concurrency = nil
+			p.concurrencyErr = fmt.Errorf("invalid concurrency limit: %d calls, %d queued, %v wait",
+				maxConcurrent, maxQueue, maxWait)
+			return
+		}
+		p.concurrency = NewConcurrencyLimiter(maxConcurrent, maxQueue, maxWait)
+		p.concurrencyErr = nil
+	}
+}
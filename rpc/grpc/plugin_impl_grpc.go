@@ -15,9 +15,13 @@
 package grpc
 
 import (
+	"context"
 	"crypto/tls"
 	"io"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
@@ -26,7 +30,8 @@ import (
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/grpclog"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	"go.ligato.io/cn-infra/v2/infra"
 	"go.ligato.io/cn-infra/v2/logging/logrus"
@@ -56,6 +61,29 @@ type Plugin struct {
 	serverOpts []grpc.ServerOption
 	metrics    *grpc_prometheus.ServerMetrics
 	limiter    *rate.Limiter
+
+	idempotencyCache IdempotencyCache
+	idempotencyTTL   time.Duration
+	streamLifetime   *StreamLifetime
+	payloadLogging   bool
+	inFlight         *InFlightTracker
+	reflection       bool
+	deferServe       bool
+	bodyReadRate     *BodyReadRate
+	version          *ServerVersion
+	listener         net.Listener
+	apiVersion       *APIVersionRange
+	recovery         bool
+	drain            *DrainCoordinator
+	grpcLogging      bool
+	certMethods      ClientCertMethods
+	connLimiter      *ConnByteLimiter
+	cancelStatus     bool
+	httpHandler      http.Handler
+	httpServer       *http.Server
+	concurrency      *ConcurrencyLimiter
+	concurrencyErr   error
+	startMu          sync.Mutex
 }
 
 // Deps is a list of injected dependencies of the GRPC plugin.
@@ -66,6 +94,10 @@ type Deps struct {
 
 // Init prepares GRPC netListener for registration of individual service
 func (p *Plugin) Init() (err error) {
+	if p.concurrencyErr != nil {
+		return p.concurrencyErr
+	}
+
 	// Get GRPC configuration file
 	if p.Config == nil {
 		p.Config, err = p.getGrpcConfig()
@@ -93,6 +125,34 @@ func (p *Plugin) Init() (err error) {
 		var unaryChain []grpc.UnaryServerInterceptor
 		var streamChain []grpc.StreamServerInterceptor
 
+		// In-flight tracking middleware (first, so it sees every call)
+		if p.inFlight != nil {
+			p.Log.Debug("In-flight request tracking for gRPC enabled")
+			unaryChain = append(unaryChain, p.inFlight.UnaryServerInterceptor())
+			streamChain = append(streamChain, p.inFlight.StreamServerInterceptor())
+		}
+
+		// Drain middleware
+		if p.drain != nil {
+			p.Log.Debug("Graceful drain for gRPC enabled")
+			unaryChain = append(unaryChain, p.drain.UnaryServerInterceptor())
+			streamChain = append(streamChain, p.drain.StreamServerInterceptor())
+		}
+
+		// Panic recovery middleware
+		if p.recovery {
+			p.Log.Debug("Panic recovery for gRPC enabled")
+			unaryChain = append(unaryChain, UnaryServerInterceptorRecovery(p.Log))
+			streamChain = append(streamChain, StreamServerInterceptorRecovery(p.Log))
+		}
+
+		// Version trailer middleware
+		if p.version != nil {
+			p.Log.Debugf("Server version %q attached to gRPC responses", p.version.Version)
+			unaryChain = append(unaryChain, UnaryServerInterceptorVersion(*p.version))
+			streamChain = append(streamChain, StreamServerInterceptorVersion(*p.version))
+		}
+
 		// Rate limiting middleware
 		if p.limiter != nil {
 			p.Log.Debugf("Rate limiter set to rate %.1f req/s (%d max burst)", p.limiter.Limit(), p.limiter.Burst())
@@ -100,6 +160,14 @@ func (p *Plugin) Init() (err error) {
 			streamChain = append(streamChain, StreamServerInterceptorLimiter(p.limiter))
 		}
 
+		// Concurrency limiting middleware
+		if p.concurrency != nil {
+			stats := p.concurrency.Stats()
+			p.Log.Debugf("Concurrency limited to %d calls (%d queued at most)", stats.MaxConcurrent, stats.MaxQueue)
+			unaryChain = append(unaryChain, p.concurrency.UnaryServerInterceptor())
+			streamChain = append(streamChain, p.concurrency.StreamServerInterceptor())
+		}
+
 		// Auth middleware
 		if p.auther != nil {
 			p.Log.Debug("Token authentication for gRPC enabled")
@@ -108,6 +176,46 @@ func (p *Plugin) Init() (err error) {
 
 		}
 
+		// Client certificate middleware
+		if len(p.certMethods) > 0 {
+			p.Log.Debugf("Verified client certificate required for %v", p.certMethods)
+			unaryChain = append(unaryChain, UnaryServerInterceptorClientCert(p.certMethods))
+			streamChain = append(streamChain, StreamServerInterceptorClientCert(p.certMethods))
+		}
+
+		// API version middleware
+		if p.apiVersion != nil {
+			p.Log.Debugf("API version check for gRPC enabled (min: %q, max: %q)", p.apiVersion.Min, p.apiVersion.Max)
+			unary, err := UnaryServerInterceptorAPIVersion(*p.apiVersion)
+			if err != nil {
+				return err
+			}
+			stream, err := StreamServerInterceptorAPIVersion(*p.apiVersion)
+			if err != nil {
+				return err
+			}
+			unaryChain = append(unaryChain, unary)
+			streamChain = append(streamChain, stream)
+		}
+
+		// Stream lifetime middleware
+		if p.streamLifetime != nil {
+			p.Log.Debugf("Maximum stream lifetime set to %v", p.streamLifetime.Default)
+			streamChain = append(streamChain, StreamServerInterceptorMaxLifetime(*p.streamLifetime))
+		}
+
+		// Idempotency middleware
+		if p.idempotencyCache != nil {
+			p.Log.Debugf("Idempotency keys enabled (TTL %v)", p.idempotencyTTL)
+			unaryChain = append(unaryChain, UnaryServerInterceptorIdempotency(p.idempotencyCache, p.idempotencyTTL))
+		}
+
+		// Payload logging middleware
+		if p.payloadLogging {
+			p.Log.Debug("Payload logging for gRPC enabled")
+			unaryChain = append(unaryChain, UnaryServerInterceptorPayloadLogging(p.Log))
+		}
+
 		// add server options for prometheus metrics
 		if p.metrics != nil {
 			p.Log.Debug("Prometheus server metrics for gRPC enabled")
@@ -115,6 +223,13 @@ func (p *Plugin) Init() (err error) {
 			streamChain = append(streamChain, p.metrics.StreamServerInterceptor())
 		}
 
+		// Cancellation status middleware (last, so that the metrics and logging see translated status)
+		if p.cancelStatus {
+			p.Log.Debug("Cancellation status translation for gRPC enabled")
+			unaryChain = append(unaryChain, UnaryServerInterceptorCancellation())
+			streamChain = append(streamChain, StreamServerInterceptorCancellation())
+		}
+
 		// get server options from config
 		opts := p.Config.getGrpcOptions()
 
@@ -123,23 +238,50 @@ func (p *Plugin) Init() (err error) {
 			grpc_middleware.WithStreamServerChain(streamChain...),
 		)
 
+		// Connection byte limit
+		if p.connLimiter != nil {
+			p.Log.Debugf("Connection byte limit set to %d B", p.connLimiter.limit)
+			p.connLimiter.log = p.Log
+		}
+
+		// Body read rate limit
+		if p.bodyReadRate != nil {
+			p.Log.Debugf("Request receive timeout set to %v", p.bodyReadRate.timeout())
+			isStream := streamMethods(func() *grpc.Server { return p.grpcServer })
+			opts = append(opts, grpc.StatsHandler(NewBodyReadRateHandler(*p.bodyReadRate, isStream, p.Log)))
+		}
+
 		// add custom server options
 		opts = append(opts, p.serverOpts...)
 
-		if p.tlsConfig != nil {
+		if p.tlsConfig != nil && p.httpHandler == nil {
 			p.Log.Debug("Secure connection (TLS) for gRPC enabled")
 			opts = append(opts, grpc.Creds(credentials.NewTLS(p.tlsConfig)))
 		}
 
 		p.grpcServer = grpc.NewServer(opts...)
+
+		if p.drain != nil && p.drain.health != nil && p.drain.registerHealth {
+			healthpb.RegisterHealthServer(p.grpcServer, p.drain.health)
+		}
+
+		if p.reflection {
+			p.Log.Debug("Server reflection for gRPC enabled")
+			reflection.Register(p.grpcServer)
+		}
 	}
 
-	grpcLogger := logrus.NewLogger("grpc-server")
-	if p.Config != nil && p.Config.ExtendedLogging {
-		p.Log.Debug("GRPC transport logging enabled")
-		grpcLogger.SetVerbosity(logLevel)
+	// Mirror gRPC internal logs (opt-in, since it is global for the process)
+	if p.grpcLogging || (p.Config != nil && p.Config.ExtendedLogging) {
+		grpcLogger := logrus.NewLogger("grpc-server")
+		if p.Config != nil && p.Config.ExtendedLogging {
+			p.Log.Debug("GRPC transport logging enabled")
+			grpcLogger.SetVerbosity(logLevel)
+		}
+		if !SetupGrpcLogging(grpcLogger) {
+			p.Log.Debug("GRPC logging already set up")
+		}
 	}
-	grpclog.SetLoggerV2(grpcLogger)
 
 	return nil
 }
@@ -155,15 +297,78 @@ func (p *Plugin) AfterInit() (err error) {
 		p.Deps.HTTP.RegisterHTTPHandler("/service", func(formatter *render.Render) http.HandlerFunc {
 			return p.grpcServer.ServeHTTP
 		}, "GET", "PUT", "POST")
+		if p.inFlight != nil {
+			p.Deps.HTTP.RegisterHTTPHandler("/service/inflight", func(formatter *render.Render) http.HandlerFunc {
+				return func(w http.ResponseWriter, req *http.Request) {
+					formatter.JSON(w, http.StatusOK, p.InFlightRequests())
+				}
+			}, "GET")
+		}
+		if p.concurrency != nil {
+			p.Deps.HTTP.RegisterHTTPHandler("/service/concurrency", func(formatter *render.Render) http.HandlerFunc {
+				return func(w http.ResponseWriter, req *http.Request) {
+					formatter.JSON(w, http.StatusOK, p.Concurrency())
+				}
+			}, "GET")
+		}
 	} else {
 		p.Log.Debugf("HTTP not set, skip exposing GRPC services")
 	}
 
+	if p.deferServe {
+		p.Log.Infof("Serving GRPC deferred until Start is called")
+		return nil
+	}
+
+	return p.Start()
+}
+
+// Start starts serving GRPC on the configured endpoint. By default, it is called from AfterInit. With the
+// UseDeferredServe option, serving is deferred until Start is called explicitly, which allows plugins
+// initialized later to register their services (gRPC does not allow registering services after the server
+// started serving). Calling Start more than once has no effect.
+func (p *Plugin) Start() (err error) {
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+
+	if p.disabled || p.netListener != nil {
+		return nil
+	}
+
 	// initialize prometheus metrics for grpc server
 	if p.metrics != nil {
 		p.metrics.InitializeMetrics(p.grpcServer)
 	}
 
+	// Serve on custom listener, or on configured listener wrapped by connection byte limiter
+	// or multiplexed with HTTP handler
+	lis := p.listener
+	if lis == nil && (p.connLimiter != nil || p.httpHandler != nil) {
+		if lis, err = listen(p.Config); err != nil {
+			return err
+		}
+	}
+	if lis != nil {
+		if p.connLimiter != nil {
+			lis = p.connLimiter.Listener(lis)
+		}
+		p.netListener = lis
+		if p.httpHandler != nil {
+			if err = p.serveMultiplexed(lis); err != nil {
+				return err
+			}
+			p.Log.Infof("Listening GRPC and HTTP on: %v", lis.Addr())
+			return nil
+		}
+		go func() {
+			err := p.grpcServer.Serve(lis)
+			// Serve always returns non-nil error
+			p.Log.Debugf("GRPC server Serve: %v", err)
+		}()
+		p.Log.Infof("Listening GRPC on: %v", lis.Addr())
+		return nil
+	}
+
 	// Start GRPC listener
 	p.netListener, err = ListenAndServe(p.Config, p.grpcServer)
 	if err != nil {
@@ -179,10 +384,59 @@ func (p *Plugin) Close() error {
 	if p.grpcServer != nil {
 		p.grpcServer.Stop()
 	}
+	if p.httpServer != nil {
+		return p.httpServer.Close()
+	}
 	return nil
 }
 
-// GetServer is a getter for accessing grpc.Server
+// Drain gracefully shuts down the server: with the UseDrain option, the services are reported as
+// NOT_SERVING, new calls are rejected and in-flight calls are awaited (see DrainCoordinator). Then the server
+// is stopped gracefully. If the context is done first, the server is stopped immediately, cancelling
+// remaining calls.
+func (p *Plugin) Drain(ctx context.Context) (err error) {
+	if p.grpcServer == nil {
+		return nil
+	}
+	if p.drain != nil {
+		p.Log.Info("Draining GRPC server")
+		err = p.drain.Drain(ctx, p.grpcServer)
+	}
+	if p.httpServer != nil {
+		// transports of gRPC served by the HTTP server (see UseHTTPHandler) do not support graceful stop,
+		// the HTTP server is shut down gracefully instead
+		if shutdownErr := p.httpServer.Shutdown(ctx); err == nil {
+			err = shutdownErr
+		}
+		p.grpcServer.Stop()
+		return err
+	}
+	stopped := make(chan struct{})
+	go func() {
+		p.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		p.grpcServer.Stop()
+		<-stopped
+		err = ctx.Err()
+	}
+	return err
+}
+
+// ConnectionBytes returns the number of bytes received so far on each open connection, if the connection
+// byte limit is set (see UseConnByteLimit).
+func (p *Plugin) ConnectionBytes() []ConnBytes {
+	if p.connLimiter == nil {
+		return nil
+	}
+	return p.connLimiter.Connections()
+}
+
+// GetServer is a getter for accessing grpc.Server. Services must be registered
+// before the server starts serving (see UseDeferredServe).
 func (p *Plugin) GetServer() *grpc.Server {
 	return p.grpcServer
 }
@@ -200,6 +454,10 @@ func (p *Plugin) getGrpcConfig() (*Config, error) {
 		return &grpcCfg, err
 	}
 	if !found {
+		if p.listener != nil {
+			p.Log.Debug("GRPC config not found, serving on custom listener with default config")
+			return &grpcCfg, nil
+		}
 		p.Log.Info("GRPC config not found, skip loading this plugin")
 		p.disabled = true
 	}
@@ -0,0 +1,97 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestDeferredServe(t *testing.T) {
+	p := NewPlugin(UseConf(Config{Endpoint: "127.0.0.1:0"}), UseDeferredServe())
+	if err := p.Init(); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	defer p.Close()
+	if err := p.AfterInit(); err != nil {
+		t.Fatalf("after init failed: %v", err)
+	}
+	if p.netListener != nil {
+		t.Fatalf("expected serving to be deferred")
+	}
+
+	// late registration is allowed before Start
+	healthpb.RegisterHealthServer(p.GetServer(), health.NewServer())
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if err := p.Start(); err != nil {
+		t.Fatalf("repeated start failed: %v", err)
+	}
+
+	addr := p.netListener.(net.Listener).Addr().String()
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("unexpected status: %v", resp.Status)
+	}
+}
+
+func TestCustomListener(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	p := NewPlugin(UseListener(lis), UseVersionTrailer(ServerVersion{Version: "test"}))
+	if err := p.Init(); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	defer p.Close()
+	healthpb.RegisterHealthServer(p.GetServer(), health.NewServer())
+	if err := p.AfterInit(); err != nil {
+		t.Fatalf("after init failed: %v", err)
+	}
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// the call passes through the full interceptor chain of the plugin
+	var trailer metadata.MD
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.Trailer(&trailer))
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	if v := trailer.Get(ServerVersionTrailer); len(v) != 1 || v[0] != "test" {
+		t.Errorf("unexpected version trailer: %v", v)
+	}
+}
@@ -0,0 +1,129 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// OpenStreamFunc opens a server-streaming call, resuming after the given token (empty on the first attempt,
+// or if no message was handled yet). It returns a function receiving the next message of the stream,
+// typically the Recv method of the generated stream client.
+type OpenStreamFunc func(ctx context.Context, resumeToken string) (recv func() (interface{}, error), err error)
+
+// StreamHandler handles a message received from the stream. It returns the token for resuming the stream
+// after this message (empty keeps the previous token). An error returned by the handler stops the stream.
+type StreamHandler func(msg interface{}) (resumeToken string, err error)
+
+// ReconnectBackoff defines delays between reconnection attempts. The delay starts at Initial and is doubled
+// after each failed attempt up to Max. It is reset once a message is received. If Initial is not set,
+// DefaultReconnectBackoff.Initial is used.
+type ReconnectBackoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// DefaultReconnectBackoff starts at 100ms and grows up to 10s.
+var DefaultReconnectBackoff = ReconnectBackoff{
+	Initial: 100 * time.Millisecond,
+	Max:     10 * time.Second,
+}
+
+// StreamWithReconnect opens the stream and passes received messages to the handler. If the stream ends
+// (io.EOF) or fails with a transient error (Unavailable, Aborted, ResourceExhausted or Internal, e.g. reset
+// stream), it is re-opened after the backoff delay, resuming after the token of the last handled message.
+// It returns the context error when the context is done, or the first permanent error of the stream or the
+// handler.
+func StreamWithReconnect(ctx context.Context, open OpenStreamFunc, handle StreamHandler, backoff ReconnectBackoff) error {
+	if backoff.Initial <= 0 {
+		backoff.Initial = DefaultReconnectBackoff.Initial
+	}
+	if backoff.Max < backoff.Initial {
+		backoff.Max = backoff.Initial
+	}
+	var resumeToken string
+	delay := backoff.Initial
+	for {
+		received, err := runStream(ctx, open, handle, &resumeToken)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if he, ok := err.(handlerError); ok {
+			return he.error
+		}
+		if !isTransientStreamError(err) {
+			return err
+		}
+		if received {
+			delay = backoff.Initial
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		if delay *= 2; delay > backoff.Max {
+			delay = backoff.Max
+		}
+	}
+}
+
+// runStream opens a single stream and receives messages until it fails, it reports if any message was received
+func runStream(ctx context.Context, open OpenStreamFunc, handle StreamHandler, resumeToken *string) (received bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	recv, err := open(ctx, *resumeToken)
+	if err != nil {
+		return false, err
+	}
+	for {
+		msg, err := recv()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		token, err := handle(msg)
+		if err != nil {
+			return received, handlerError{err}
+		}
+		if token != "" {
+			*resumeToken = token
+		}
+	}
+}
+
+// handlerError marks errors returned by the handler, which always stop the stream
+type handlerError struct {
+	error
+}
+
+// isTransientStreamError returns true for errors after which the stream should be re-opened
+func isTransientStreamError(err error) bool {
+	if err == io.EOF {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Aborted, codes.ResourceExhausted, codes.Internal:
+		return true
+	}
+	return false
+}
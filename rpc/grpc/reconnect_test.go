@@ -0,0 +1,95 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStreams opens streams returning given messages, each stream ending with the given error
+func fakeStreams(tokens *[]string, streams ...[]interface{}) OpenStreamFunc {
+	attempt := 0
+	return func(ctx context.Context, resumeToken string) (func() (interface{}, error), error) {
+		*tokens = append(*tokens, resumeToken)
+		if attempt >= len(streams) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		msgs := streams[attempt]
+		attempt++
+		return func() (interface{}, error) {
+			msg := msgs[0]
+			msgs = msgs[1:]
+			if err, ok := msg.(error); ok {
+				return nil, err
+			}
+			return msg, nil
+		}, nil
+	}
+}
+
+func TestStreamWithReconnect(t *testing.T) {
+	var tokens []string
+	var received []int
+	open := fakeStreams(&tokens,
+		[]interface{}{1, 2, io.EOF},
+		[]interface{}{status.Error(codes.Unavailable, "connection reset")},
+		[]interface{}{3, status.Error(codes.Internal, "stream reset")},
+		[]interface{}{4, status.Error(codes.PermissionDenied, "denied")},
+	)
+	handle := func(msg interface{}) (string, error) {
+		received = append(received, msg.(int))
+		return strconv.Itoa(msg.(int)), nil
+	}
+
+	err := StreamWithReconnect(context.Background(), open, handle, ReconnectBackoff{Initial: time.Millisecond})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected permanent error, got %v", err)
+	}
+	if expected := []int{1, 2, 3, 4}; !reflect.DeepEqual(received, expected) {
+		t.Errorf("expected messages %v, got %v", expected, received)
+	}
+	if expected := []string{"", "2", "2", "3"}; !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expected resume tokens %v, got %v", expected, tokens)
+	}
+}
+
+func TestStreamWithReconnectStop(t *testing.T) {
+	var tokens []string
+	handlerErr := errors.New("handler failure")
+	err := StreamWithReconnect(context.Background(), fakeStreams(&tokens, []interface{}{1, 2}),
+		func(msg interface{}) (string, error) {
+			return "", handlerErr
+		}, DefaultReconnectBackoff)
+	if err != handlerErr {
+		t.Errorf("expected handler error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = StreamWithReconnect(ctx, fakeStreams(&tokens), nil, DefaultReconnectBackoff)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context error, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.ligato.io/cn-infra/v2/logging"
+)
+
+// UnaryServerInterceptorRecovery returns a new unary server interceptor that recovers from panics
+// in handlers. The panic is logged with its stack (see logging.LogPanic) and the call fails with Internal.
+func UnaryServerInterceptorRecovery(log logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.LogPanic(log.WithField("method", info.FullMethod), r)
+				err = status.Errorf(codes.Internal, "%s failed: internal error", info.FullMethod)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptorRecovery returns a new stream server interceptor that recovers from panics
+// in handlers. The panic is logged with its stack (see logging.LogPanic) and the stream fails with Internal.
+func StreamServerInterceptorRecovery(log logging.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.LogPanic(log.WithField("method", info.FullMethod), r)
+				err = status.Errorf(codes.Internal, "%s failed: internal error", info.FullMethod)
+			}
+		}()
+		return handler(srv, stream)
+	}
+}
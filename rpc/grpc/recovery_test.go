@@ -0,0 +1,62 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	lg "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.ligato.io/cn-infra/v2/logging"
+	"go.ligato.io/cn-infra/v2/logging/logrus"
+)
+
+func TestRecovery(t *testing.T) {
+	log := logrus.NewLogger("recovery-test")
+	log.SetFormatter(&lg.JSONFormatter{})
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+
+	interceptor := UnaryServerInterceptorRecovery(log)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Panic"}
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("handler failure")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected Internal error, got %v", err)
+	}
+
+	var entry struct {
+		Level  string   `json:"level"`
+		Method string   `json:"method"`
+		Panic  string   `json:"panic"`
+		Stack  []string `json:"stack"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log entry %q: %v", buffer.String(), err)
+	}
+	if entry.Level != logging.ErrorLevel.String() || entry.Method != info.FullMethod || entry.Panic != "handler failure" {
+		t.Errorf("unexpected log entry: %+v", entry)
+	}
+	if len(entry.Stack) == 0 || !bytes.Contains([]byte(entry.Stack[0]), []byte("TestRecovery")) {
+		t.Errorf("stack should start at the panicking function: %v", entry.Stack)
+	}
+}
@@ -0,0 +1,68 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamLifetime defines maximum duration of streaming calls.
+type StreamLifetime struct {
+	// Default is the limit for all streams not listed in Methods. Zero means no limit.
+	Default time.Duration
+	// Methods maps full method names (e.g. "/pkg.Service/Method") to their limits. Zero means no limit.
+	Methods map[string]time.Duration
+}
+
+func (l StreamLifetime) forMethod(method string) time.Duration {
+	if limit, ok := l.Methods[method]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// StreamServerInterceptorMaxLifetime returns a new stream server interceptor that cancels the stream
+// context of server-streaming calls once the maximum lifetime for the method elapses. Handlers are expected
+// to return when the stream context is done, the call is then finished with DeadlineExceeded status.
+// Result of a handler which returns before the limit is kept. Client-streaming and bidirectional streams
+// are skipped, since the duration of those is driven by the client.
+func StreamServerInterceptorMaxLifetime(lifetime StreamLifetime) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		limit := lifetime.forMethod(info.FullMethod)
+		if limit <= 0 || !info.IsServerStream || info.IsClientStream {
+			return handler(srv, stream)
+		}
+
+		ctx, cancel := context.WithTimeout(stream.Context(), limit)
+		defer cancel()
+
+		wrapped := grpc_middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = ctx
+
+		err := handler(srv, wrapped)
+		deadline, _ := ctx.Deadline()
+		if err != nil && !time.Now().Before(deadline) &&
+			ctx.Err() == context.DeadlineExceeded && stream.Context().Err() == nil {
+			return status.Errorf(codes.DeadlineExceeded, "%s exceeded maximum stream lifetime of %v", info.FullMethod, limit)
+		}
+		return err
+	}
+}
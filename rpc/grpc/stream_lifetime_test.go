@@ -0,0 +1,94 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testServerStream is a server stream with the given context
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamMaxLifetime(t *testing.T) {
+	interceptor := StreamServerInterceptorMaxLifetime(StreamLifetime{
+		Default: 50 * time.Millisecond,
+		Methods: map[string]time.Duration{"/test.Service/Unlimited": 0},
+	})
+	stream := &testServerStream{ctx: context.Background()}
+	serverStream := &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch", IsServerStream: true}
+
+	// handler waiting for the stream context is cancelled after the limit
+	waiting := func(srv interface{}, stream grpc.ServerStream) error {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}
+	start := time.Now()
+	err := interceptor(nil, stream, serverStream, waiting)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("stream was not cancelled in time (%v)", elapsed)
+	}
+
+	// result of handler returning in time is kept
+	notFound := status.Error(codes.NotFound, "not found")
+	err = interceptor(nil, stream, serverStream, func(srv interface{}, stream grpc.ServerStream) error {
+		return notFound
+	})
+	if err != notFound {
+		t.Errorf("expected handler error, got %v", err)
+	}
+	err = interceptor(nil, stream, serverStream, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// streams driven by the client and methods without limit are not limited
+	for _, info := range []*grpc.StreamServerInfo{
+		{FullMethod: "/test.Service/Upload", IsClientStream: true},
+		{FullMethod: "/test.Service/Chat", IsClientStream: true, IsServerStream: true},
+		{FullMethod: "/test.Service/Unlimited", IsServerStream: true},
+	} {
+		err = interceptor(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+			if _, ok := stream.Context().Deadline(); ok {
+				t.Errorf("%s: unexpected deadline", info.FullMethod)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", info.FullMethod, err)
+		}
+	}
+}
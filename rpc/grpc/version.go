@@ -0,0 +1,75 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Trailer keys of the server version metadata.
+const (
+	ServerVersionTrailer   = "x-server-version"
+	ServerBuildTimeTrailer = "x-server-build-time"
+	ServerHostnameTrailer  = "x-server-hostname"
+)
+
+// ServerVersion is the version information attached to every response by UseVersionTrailer.
+type ServerVersion struct {
+	// Version (e.g. release tag and commit) sent in the x-server-version trailer
+	Version string
+	// BuildTime is sent in the x-server-build-time trailer if not empty
+	BuildTime string
+	// IncludeHostname sends the host name in the x-server-hostname trailer
+	IncludeHostname bool
+}
+
+// metadata builds the trailer metadata from the version info.
+func (v ServerVersion) metadata() metadata.MD {
+	md := metadata.Pairs(ServerVersionTrailer, v.Version)
+	if v.BuildTime != "" {
+		md.Set(ServerBuildTimeTrailer, v.BuildTime)
+	}
+	if v.IncludeHostname {
+		if hostname, err := os.Hostname(); err == nil {
+			md.Set(ServerHostnameTrailer, hostname)
+		}
+	}
+	return md
+}
+
+// UnaryServerInterceptorVersion returns a new unary server interceptor that attaches the server version
+// to the response trailer. The trailer metadata is prepared once, so the per-call overhead is negligible.
+func UnaryServerInterceptorVersion(v ServerVersion) grpc.UnaryServerInterceptor {
+	md := v.metadata()
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		// the trailer is best-effort, it must never fail the call
+		_ = grpc.SetTrailer(ctx, md)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptorVersion returns a new stream server interceptor that attaches the server version
+// to the stream trailer.
+func StreamServerInterceptorVersion(v ServerVersion) grpc.StreamServerInterceptor {
+	md := v.metadata()
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream.SetTrailer(md)
+		return handler(srv, stream)
+	}
+}
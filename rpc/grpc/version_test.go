@@ -0,0 +1,61 @@
+//  Copyright (c) 2020 Cisco and/or its affiliates.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at:
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestVersionTrailer(t *testing.T) {
+	version := ServerVersion{Version: "v2.5.0-1-gabcdef", BuildTime: "2020-05-01T10:00:00Z", IncludeHostname: true}
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptorVersion(version)))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	var trailer metadata.MD
+	client := healthpb.NewHealthClient(conn)
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.Trailer(&trailer)); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if v := trailer.Get(ServerVersionTrailer); len(v) != 1 || v[0] != version.Version {
+		t.Errorf("unexpected version trailer: %v", v)
+	}
+	if v := trailer.Get(ServerBuildTimeTrailer); len(v) != 1 || v[0] != version.BuildTime {
+		t.Errorf("unexpected build time trailer: %v", v)
+	}
+	if v := trailer.Get(ServerHostnameTrailer); len(v) != 1 || v[0] == "" {
+		t.Errorf("unexpected hostname trailer: %v", v)
+	}
+}